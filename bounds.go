@@ -0,0 +1,152 @@
+package semver
+
+// Bound is one end of the span of versions a Set allows. An Unbounded lower
+// Bound takes in every version below the upper one, and an Unbounded upper
+// Bound every version above the lower one.
+type Bound struct {
+	Version   Version
+	Inclusive bool
+	Unbounded bool
+}
+
+func (b bound) export() Bound {
+	if b.unbounded {
+		return Bound{Unbounded: true}
+	}
+
+	return Bound{Version: cloneVersion(b.version), Inclusive: b.inclusive}
+}
+
+// Bounds returns the lowest and highest ends of the versions s allows. The
+// final return value is false if no version satisfies s at all. A Set that
+// excludes versions with != may have gaps between its bounds.
+func (s Set) Bounds() (Bound, Bound, bool) {
+	l := normalize(setIntervals(s))
+	if len(l) == 0 {
+		return Bound{}, Bound{}, false
+	}
+
+	return l[0].lower.export(), l[len(l)-1].upper.export(), true
+}
+
+// LowerBound is the lower half of Bounds.
+func (s Set) LowerBound() (Bound, bool) {
+	lower, _, ok := s.Bounds()
+
+	return lower, ok
+}
+
+// UpperBound is the upper half of Bounds.
+func (s Set) UpperBound() (Bound, bool) {
+	_, upper, ok := s.Bounds()
+
+	return upper, ok
+}
+
+// successor returns the lowest version above v, preferring one without a
+// prerelease the same way node-semver's minVersion does.
+func (i interval) successor() Version {
+	v := cloneVersion(i.lower.version)
+	v.Build = nil
+
+	if len(v.Prerelease) > 0 {
+		v.Prerelease = append(v.Prerelease, "0")
+
+		return v
+	}
+
+	v.Patch++
+
+	if !i.upper.below(v) {
+		return v
+	}
+
+	v.Prerelease = []string{"0"}
+
+	return v
+}
+
+// MinVersion returns the lowest version that satisfies r, or false if none
+// does. Like node-semver, it prefers 0.0.0 over 0.0.0-0 where both would
+// satisfy r.
+func (r Range) MinVersion() (Version, bool) {
+	m := r.Compile()
+	if len(m.intervals) == 0 {
+		return Version{}, false
+	}
+
+	i := m.intervals[0]
+
+	switch {
+	case i.lower.unbounded:
+		if m.SatisfiedBy(Version{}) {
+			return Version{}, true
+		}
+
+		return cloneVersion(minVersion), true
+	case i.lower.inclusive:
+		v := cloneVersion(i.lower.version)
+		v.Build = nil
+
+		return v, true
+	}
+
+	return i.successor(), true
+}
+
+// IsEmpty reports whether no version satisfies r.
+func (r Range) IsEmpty() bool {
+	return len(r.Compile().intervals) == 0
+}
+
+// IsUnbounded reports whether r has no upper limit, so that versions
+// arbitrarily far above the ones that satisfy it today will satisfy it too.
+func (r Range) IsUnbounded() bool {
+	m := r.Compile()
+
+	return len(m.intervals) > 0 && m.intervals[len(m.intervals)-1].upper.unbounded
+}
+
+// IsExact reports whether r is satisfied by exactly one version, ignoring
+// build metadata.
+func (r Range) IsExact() bool {
+	m := r.Compile()
+	if len(m.intervals) != 1 {
+		return false
+	}
+
+	i := m.intervals[0]
+
+	return !i.lower.unbounded && !i.upper.unbounded && i.lower.inclusive && i.upper.inclusive && comparePrecedence(i.lower.version, i.upper.version) == 0
+}
+
+// Outside reports whether v lies entirely to one side of r: above every
+// version satisfying r when hilo is OperatorGT, or below every one of them
+// when hilo is OperatorLT. A version in a gap between r's Sets is not outside
+// r, and neither is anything when nothing satisfies r. Any other hilo
+// returns false.
+func (r Range) Outside(v Version, hilo Operator) bool {
+	m := r.Compile()
+	if len(m.intervals) == 0 {
+		return false
+	}
+
+	switch hilo {
+	case OperatorGT:
+		return m.intervals[len(m.intervals)-1].upper.below(v)
+	case OperatorLT:
+		return m.intervals[0].lower.above(v)
+	}
+
+	return false
+}
+
+// GreaterThanRange reports whether v is newer than every version r allows.
+func (v Version) GreaterThanRange(r Range) bool {
+	return r.Outside(v, OperatorGT)
+}
+
+// LessThanRange reports whether v is older than every version r allows.
+func (v Version) LessThanRange(r Range) bool {
+	return r.Outside(v, OperatorLT)
+}
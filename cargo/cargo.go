@@ -0,0 +1,524 @@
+// Package cargo reads the dependencies declared in a Rust crate's Cargo.toml
+// and the packages pinned in its Cargo.lock, and checks them against each
+// other.
+package cargo
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/deoxxa/semver"
+)
+
+// ParseRequirement parses a Cargo version requirement, like "1.2", "~1.2.3"
+// or ">= 1.2, < 1.5", into a Range. Unlike npm, Cargo reads a bare version
+// as a caret requirement, and separates comparators with commas.
+//
+// Upper bounds end at "-0", so "^1.2" is ">=1.2.0 <2.0.0-0" and leaves out
+// prereleases of 2.0.0 as Cargo does. Cargo also leaves out prereleases of
+// other versions unless a comparator names one with the same numbers, which
+// a Range can't express; they're compared by precedence like any other
+// version.
+func ParseRequirement(s string) (semver.Range, error) {
+	var l []string
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("%s: empty comparator", s)
+		}
+
+		c, err := comparators(part)
+		if err != nil {
+			return nil, err
+		}
+
+		l = append(l, c...)
+	}
+
+	r, err := semver.ParseRange(strings.Join(l, " "))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s, err)
+	}
+
+	return r, nil
+}
+
+var operators = []string{">=", "<=", "=", ">", "<", "~", "^"}
+
+func comparators(s string) ([]string, error) {
+	var op string
+
+	for _, o := range operators {
+		if strings.HasPrefix(s, o) {
+			op, s = o, strings.TrimSpace(s[len(o):])
+			break
+		}
+	}
+
+	var pre string
+	if i := strings.IndexAny(s, "-+"); i != -1 {
+		if s[i] == '-' {
+			pre = strings.SplitN(s[i:], "+", 2)[0]
+		}
+
+		s = s[:i]
+	}
+
+	var nums [3]int64
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("%s: too many version parts", s)
+	}
+
+	fixed := len(parts)
+
+	for i, p := range parts {
+		if p == "*" || p == "x" || p == "X" {
+			if op != "" {
+				return nil, fmt.Errorf("%s: wildcards can't be used with %s", s, op)
+			}
+
+			fixed = i
+			break
+		}
+
+		n, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s: invalid version", s)
+		}
+
+		nums[i] = n
+	}
+
+	if fixed < 3 && pre != "" {
+		return nil, fmt.Errorf("%s: a prerelease needs a complete version", s)
+	}
+
+	base := fmt.Sprintf("%d.%d.%d%s", nums[0], nums[1], nums[2], pre)
+
+	// next is the first prerelease of the release after base that bumps
+	// the given part.
+	next := func(part int) string {
+		n := nums
+		n[part]++
+
+		for i := part + 1; i < 3; i++ {
+			n[i] = 0
+		}
+
+		return fmt.Sprintf("%d.%d.%d-0", n[0], n[1], n[2])
+	}
+
+	// upper is the bump that ends a partial version like "1.2", or -1 if
+	// the version is complete.
+	upper := fixed - 1
+	if fixed == 3 {
+		upper = -1
+	}
+
+	switch op {
+	case "=":
+		if upper == -1 {
+			return []string{"=" + base}, nil
+		}
+
+		return []string{">=" + base, "<" + next(upper)}, nil
+	case ">":
+		if upper == -1 {
+			return []string{">" + base}, nil
+		}
+
+		return []string{">=" + strings.TrimSuffix(next(upper), "-0")}, nil
+	case ">=":
+		return []string{">=" + base}, nil
+	case "<":
+		return []string{"<" + base}, nil
+	case "<=":
+		if upper == -1 {
+			return []string{"<=" + base}, nil
+		}
+
+		return []string{"<" + next(upper)}, nil
+	case "~":
+		if fixed == 1 {
+			return []string{">=" + base, "<" + next(0)}, nil
+		}
+
+		return []string{">=" + base, "<" + next(1)}, nil
+	}
+
+	if fixed == 0 {
+		return []string{">=0.0.0"}, nil
+	}
+
+	if len(parts) > fixed {
+		return []string{">=" + base, "<" + next(fixed-1)}, nil
+	}
+
+	switch {
+	case nums[0] > 0 || fixed == 1:
+		return []string{">=" + base, "<" + next(0)}, nil
+	case nums[1] > 0 || fixed == 2:
+		return []string{">=" + base, "<" + next(1)}, nil
+	}
+
+	return []string{">=" + base, "<" + next(2)}, nil
+}
+
+type DependencyKind string
+
+const (
+	Normal DependencyKind = "normal"
+	Dev    DependencyKind = "dev"
+	Build  DependencyKind = "build"
+)
+
+var tables = map[string]DependencyKind{
+	"dependencies":       Normal,
+	"dev-dependencies":   Dev,
+	"dev_dependencies":   Dev,
+	"build-dependencies": Build,
+	"build_dependencies": Build,
+}
+
+// Dependency is an entry in one of Cargo.toml's dependency tables. Name is
+// the name it's declared under, and Package the crate it refers to, which is
+// different for renamed dependencies. Target is the platform it's limited to,
+// if any. Range is nil when there's no version requirement, as for some path
+// and git dependencies.
+type Dependency struct {
+	Name        string
+	Package     string
+	Kind        DependencyKind
+	Target      string
+	Requirement string
+	Range       semver.Range
+	Path        string
+	Git         string
+	Optional    bool
+}
+
+type Manifest struct {
+	Name         string
+	Version      string
+	Dependencies []Dependency
+}
+
+func parseDependency(name string, kind DependencyKind, target string, value any, workspace map[string]any) (Dependency, error) {
+	d := Dependency{Name: name, Package: name, Kind: kind, Target: target}
+
+	switch v := value.(type) {
+	case string:
+		d.Requirement = v
+	case map[string]any:
+		if inherit, _ := v["workspace"].(bool); inherit {
+			w, ok := workspace[name]
+			if !ok {
+				return d, fmt.Errorf("%s: not found in workspace.dependencies", name)
+			}
+
+			inherited, err := parseDependency(name, kind, target, w, nil)
+			if err != nil {
+				return d, err
+			}
+
+			d = inherited
+		}
+
+		if s, ok := v["version"].(string); ok {
+			d.Requirement = s
+		}
+
+		if s, ok := v["package"].(string); ok {
+			d.Package = s
+		}
+
+		if s, ok := v["path"].(string); ok {
+			d.Path = s
+		}
+
+		if s, ok := v["git"].(string); ok {
+			d.Git = s
+		}
+
+		if b, ok := v["optional"].(bool); ok {
+			d.Optional = b
+		}
+	default:
+		return d, fmt.Errorf("%s: unexpected %T", name, value)
+	}
+
+	if d.Requirement == "" {
+		if d.Path == "" && d.Git == "" {
+			d.Requirement = "*"
+		} else {
+			return d, nil
+		}
+	}
+
+	r, err := ParseRequirement(d.Requirement)
+	if err != nil {
+		return d, fmt.Errorf("%s: %w", name, err)
+	}
+
+	d.Range = r
+
+	return d, nil
+}
+
+func addDependencies(m *Manifest, t map[string]any, target string, workspace map[string]any) error {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, table := range names {
+		kind, ok := tables[table]
+		if !ok {
+			continue
+		}
+
+		deps, _ := t[table].(map[string]any)
+
+		l := make([]string, 0, len(deps))
+		for name := range deps {
+			l = append(l, name)
+		}
+
+		sort.Strings(l)
+
+		for _, name := range l {
+			d, err := parseDependency(name, kind, target, deps[name], workspace)
+			if err != nil {
+				return err
+			}
+
+			m.Dependencies = append(m.Dependencies, d)
+		}
+	}
+
+	return nil
+}
+
+// ParseManifest reads the dependency tables of a Cargo.toml file, including
+// platform-specific ones, and resolves dependencies inherited from the
+// workspace.dependencies table of the same file. Dependencies are ordered by
+// target, then table, then name.
+func ParseManifest(data []byte) (*Manifest, error) {
+	var raw map[string]any
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+
+	if p, ok := raw["package"].(map[string]any); ok {
+		m.Name, _ = p["name"].(string)
+		m.Version, _ = p["version"].(string)
+	}
+
+	var workspace map[string]any
+	if w, ok := raw["workspace"].(map[string]any); ok {
+		workspace, _ = w["dependencies"].(map[string]any)
+	}
+
+	if err := addDependencies(&m, raw, "", workspace); err != nil {
+		return nil, err
+	}
+
+	targets, _ := raw["target"].(map[string]any)
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		t, _ := targets[name].(map[string]any)
+
+		if err := addDependencies(&m, t, name, workspace); err != nil {
+			return nil, err
+		}
+	}
+
+	return &m, nil
+}
+
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := ParseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// LockedPackage is a [[package]] entry in Cargo.lock. Source is empty for
+// crates in the local workspace.
+type LockedPackage struct {
+	Name    string
+	Version semver.Version
+	Source  string
+}
+
+type Lock struct {
+	Packages []LockedPackage
+}
+
+func ParseLock(data []byte) (*Lock, error) {
+	var raw struct {
+		Package []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+			Source  string `toml:"source"`
+		} `toml:"package"`
+	}
+
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, err
+	}
+
+	var l Lock
+
+	for _, p := range raw.Package {
+		v, err := semver.ParseVersion(p.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", p.Name, p.Version, err)
+		}
+
+		l.Packages = append(l.Packages, LockedPackage{Name: p.Name, Version: v, Source: p.Source})
+	}
+
+	return &l, nil
+}
+
+func ReadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := ParseLock(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return l, nil
+}
+
+func (l *Lock) versions(name string) semver.List {
+	var r semver.List
+
+	for _, p := range l.Packages {
+		if p.Name == name {
+			r = append(r, p.Version)
+		}
+	}
+
+	return r
+}
+
+// Mismatch is a dependency that none of the locked versions of its crate
+// satisfy. Locked is empty if the crate isn't in the lock file at all.
+type Mismatch struct {
+	Dependency Dependency
+	Locked     semver.List
+}
+
+func (m Mismatch) String() string {
+	if len(m.Locked) == 0 {
+		return fmt.Sprintf("%s: not in lock file", m.Dependency.Name)
+	}
+
+	l := make([]string, len(m.Locked))
+	for i, v := range m.Locked {
+		l[i] = v.String()
+	}
+
+	return fmt.Sprintf("%s: %s doesn't satisfy %q", m.Dependency.Name, strings.Join(l, ", "), m.Dependency.Requirement)
+}
+
+// Check reports each dependency in m with a version requirement that isn't
+// satisfied by any version of its crate locked in l.
+func Check(m *Manifest, l *Lock) []Mismatch {
+	var r []Mismatch
+
+	for _, d := range m.Dependencies {
+		if d.Range == nil {
+			continue
+		}
+
+		locked := l.versions(d.Package)
+
+		if _, ok := d.Range.BestMatchSeq(locked.Values()); !ok {
+			r = append(r, Mismatch{Dependency: d, Locked: locked})
+		}
+	}
+
+	return r
+}
+
+// Duplicate is a crate locked at more than one incompatible version, so that
+// it's built more than once.
+type Duplicate struct {
+	Name     string
+	Versions semver.List
+}
+
+// line returns the part of v that Cargo treats as its major version: the
+// major number from 1.0.0 on, then the minor number, then the patch number.
+func line(v semver.Version) [3]int64 {
+	switch {
+	case v.Major > 0:
+		return [3]int64{v.Major, -1, -1}
+	case v.Minor > 0:
+		return [3]int64{0, v.Minor, -1}
+	}
+
+	return [3]int64{0, 0, v.Patch}
+}
+
+// Duplicates reports each crate that l holds at more than one major version,
+// in Cargo's sense that 0.2 and 0.3 are different major versions, sorted by
+// name. Versions holds every locked version of the crate, in order.
+func (l *Lock) Duplicates() []Duplicate {
+	byName := map[string]semver.List{}
+
+	for _, p := range l.Packages {
+		byName[p.Name] = append(byName[p.Name], p.Version)
+	}
+
+	var r []Duplicate
+
+	for name, versions := range byName {
+		lines := map[[3]int64]bool{}
+		for _, v := range versions {
+			lines[line(v)] = true
+		}
+
+		if len(lines) > 1 {
+			versions.SortStable()
+			r = append(r, Duplicate{Name: name, Versions: versions})
+		}
+	}
+
+	sort.Slice(r, func(i, j int) bool { return r[i].Name < r[j].Name })
+
+	return r
+}
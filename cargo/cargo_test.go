@@ -0,0 +1,158 @@
+package cargo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+func TestParseRequirement(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		in, out string
+		yes, no []string
+	}{
+		{"1.2.3", ">=1.2.3 <2.0.0-0", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0", "2.0.0-alpha"}},
+		{"^1.2", ">=1.2.0 <2.0.0-0", []string{"1.2.0", "1.99.0"}, []string{"1.1.9"}},
+		{"0.2.3", ">=0.2.3 <0.3.0-0", []string{"0.2.9"}, []string{"0.3.0"}},
+		{"0.0.3", ">=0.0.3 <0.0.4-0", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"0.0", ">=0.0.0 <0.1.0-0", []string{"0.0.7"}, []string{"0.1.0"}},
+		{"0", ">=0.0.0 <1.0.0-0", []string{"0.9.0"}, []string{"1.0.0"}},
+		{"~1.2.3", ">=1.2.3 <1.3.0-0", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"~1", ">=1.0.0 <2.0.0-0", []string{"1.5.0"}, []string{"2.0.0"}},
+		{"=1.2.3", "=1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"=1.2", ">=1.2.0 <1.3.0-0", []string{"1.2.5"}, []string{"1.3.0"}},
+		{">1.2", ">=1.3.0", []string{"1.3.0"}, []string{"1.2.9"}},
+		{">1.2.3", ">1.2.3", []string{"1.2.4"}, []string{"1.2.3"}},
+		{"<=1.2", "<1.3.0-0", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"<1.2", "<1.2.0", []string{"1.1.9"}, []string{"1.2.0"}},
+		{">= 1.2, < 1.5", ">=1.2.0 <1.5.0", []string{"1.4.9"}, []string{"1.5.0", "1.1.0"}},
+		{"*", ">=0.0.0", []string{"0.0.0", "9.9.9"}, nil},
+		{"1.*", ">=1.0.0 <2.0.0-0", []string{"1.9.0"}, []string{"2.0.0"}},
+		{"1.2.*", ">=1.2.0 <1.3.0-0", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"1.0.0-beta.2", ">=1.0.0-beta.2 <2.0.0-0", []string{"1.0.0-beta.3", "1.0.0"}, []string{"1.0.0-beta.1"}},
+	}
+
+	for _, p := range pairs {
+		r, err := ParseRequirement(p.in)
+		if !a.NoError(err, p.in) {
+			continue
+		}
+
+		a.Equal(p.out, r.String(), p.in)
+
+		for _, s := range p.yes {
+			v, err := semver.ParseVersion(s)
+			a.NoError(err)
+			a.True(r.SatisfiedBy(v), "%s: %s", p.in, s)
+		}
+
+		for _, s := range p.no {
+			v, err := semver.ParseVersion(s)
+			a.NoError(err)
+			a.False(r.SatisfiedBy(v), "%s: %s", p.in, s)
+		}
+	}
+
+	for _, s := range []string{"", "1.2,", "latest", "^1.*", "1.2.3.4", "1.2-beta"} {
+		_, err := ParseRequirement(s)
+		a.Error(err, s)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := ReadManifest("testdata/Cargo.toml")
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("example", m.Name)
+	a.Equal("0.1.0", m.Version)
+
+	var l []string
+	for _, d := range m.Dependencies {
+		s := string(d.Kind) + " " + d.Name
+		if d.Package != d.Name {
+			s += "=" + d.Package
+		}
+
+		if d.Target != "" {
+			s += " [" + d.Target + "]"
+		}
+
+		if d.Range != nil {
+			s += " " + d.Range.String()
+		}
+
+		l = append(l, s)
+	}
+
+	a.Equal([]string{
+		"build cc >=1.0.79 <2.0.0-0",
+		"normal anyhow >=1.0.40 <1.0.70",
+		"normal itoa >=0.0.0",
+		"normal json=serde_json >=1.0.0 <2.0.0-0",
+		"normal local",
+		"normal log =0.4.17",
+		"normal memchr >=2.5.0 <3.0.0-0",
+		"normal rand >=0.8.0 <0.9.0-0",
+		"normal regex >=1.9.1 <1.10.0-0",
+		"normal serde >=1.0.190 <2.0.0-0",
+		"normal tokio >=1.28.0 <2.0.0-0",
+		"dev criterion >=0.4.0 <0.5.0-0",
+		"normal libc [cfg(unix)] >=0.2.140 <0.3.0-0",
+	}, l)
+
+	a.Equal("../local", m.Dependencies[4].Path)
+	a.True(m.Dependencies[6].Optional)
+
+	_, err = ParseManifest([]byte("[dependencies]\nfoo = \"nope\"\n"))
+	a.EqualError(err, "foo: nope: invalid version")
+
+	_, err = ParseManifest([]byte("[dependencies]\nfoo = { workspace = true }\n"))
+	a.EqualError(err, "foo: not found in workspace.dependencies")
+}
+
+func TestCheck(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := ReadManifest("testdata/Cargo.toml")
+	if !a.NoError(err) {
+		return
+	}
+
+	l, err := ReadLock("testdata/Cargo.lock")
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Len(l.Packages, 16)
+	a.Equal("", l.Packages[3].Source)
+
+	var mismatches []string
+	for _, mm := range Check(m, l) {
+		mismatches = append(mismatches, mm.String())
+	}
+
+	a.Equal([]string{
+		`anyhow: 1.0.75 doesn't satisfy ">= 1.0.40, < 1.0.70"`,
+		`log: 0.4.20 doesn't satisfy "=0.4.17"`,
+		`memchr: not in lock file`,
+		`regex: 1.10.2 doesn't satisfy "~1.9.1"`,
+	}, mismatches)
+
+	var duplicates []string
+	for _, d := range l.Duplicates() {
+		duplicates = append(duplicates, d.Name+" "+d.Versions[0].String()+" "+d.Versions[1].String())
+	}
+
+	a.Equal([]string{"rand 0.7.3 0.8.5", "syn 1.0.109 2.0.38"}, duplicates)
+
+	_, err = ParseLock([]byte("[[package]]\nname = \"x\"\nversion = \"1.0\"\n"))
+	a.EqualError(err, "x 1.0: minor version should be followed by a period")
+}
@@ -0,0 +1,37 @@
+// Command semverd serves version and range queries over HTTP. See package
+// github.com/deoxxa/semver/server for the endpoints it exposes.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/deoxxa/semver/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	maxBody := flag.Int64("max-body", server.DefaultMaxBodyBytes, "maximum request body size in bytes")
+	maxVersions := flag.Int("max-versions", server.DefaultMaxVersions, "maximum number of versions in one request")
+	maxLength := flag.Int("max-length", server.DefaultMaxLength, "maximum length of a version or range")
+	flag.Parse()
+
+	s := &http.Server{
+		Addr: *addr,
+		Handler: &server.Handler{
+			MaxBodyBytes: *maxBody,
+			MaxVersions:  *maxVersions,
+			MaxLength:    *maxLength,
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	log.Printf("listening on %s", *addr)
+
+	log.Fatal(s.ListenAndServe())
+}
@@ -0,0 +1,138 @@
+// Package compat answers questions about which versions of the components
+// of a system work together.
+//
+// A Matrix is a list of entries, each saying that some versions of one
+// component support given ranges of others:
+//
+//	m := &compat.Matrix{Entries: []compat.Entry{
+//		{Component: "server", Versions: mustRange(">=3.0.0 <4.0.0"), Supports: map[string]semver.Range{
+//			"cli": mustRange(">=2.4.0"),
+//			"sdk": mustRange(">=1.8.0"),
+//		}},
+//		{Component: "sdk", Versions: mustRange(">=2.0.0"), Supports: map[string]semver.Range{
+//			"server": mustRange(">=3.2.0"),
+//		}},
+//	}}
+//
+// Constraints apply in both directions, so here sdk 2.x doesn't work with
+// server 3.1.0 even though the server's own entry allows it. Where several
+// entries cover the same version their constraints all apply.
+package compat
+
+import (
+	"sort"
+
+	"github.com/deoxxa/semver"
+)
+
+// Entry declares that the Versions of Component support only the given
+// ranges of each component named in Supports. Components it doesn't name
+// are unconstrained. To declare a single version, use a range like
+// "=3.4.1".
+type Entry struct {
+	Component string
+	Versions  semver.Range
+	Supports  map[string]semver.Range
+}
+
+type Matrix struct {
+	Entries []Entry
+}
+
+// everything is satisfied by every version.
+var everything = semver.Range{semver.Set{}}
+
+// Known reports whether the matrix mentions version v of component, either
+// in an entry for the component or in another entry's ranges of it. A
+// component the matrix says nothing about at all is always known.
+func (m *Matrix) Known(component string, v semver.Version) bool {
+	found := false
+
+	for _, e := range m.Entries {
+		r, ok := e.Versions, e.Component == component
+		if !ok {
+			r, ok = e.Supports[component]
+		}
+
+		if !ok {
+			continue
+		}
+
+		if r.SatisfiedBy(v) {
+			return true
+		}
+
+		found = true
+	}
+
+	return !found
+}
+
+// Allowed returns the versions of other that version v of component works
+// with. These are the versions allowed by every entry covering v, less
+// those covered by any entry for other that doesn't allow v.
+func (m *Matrix) Allowed(component string, v semver.Version, other string) semver.Range {
+	r := everything
+
+	for _, e := range m.Entries {
+		switch {
+		case e.Component == component && e.Versions.SatisfiedBy(v):
+			if s, ok := e.Supports[other]; ok {
+				r = r.Intersect(s)
+			}
+		case e.Component == other:
+			if s, ok := e.Supports[component]; ok && !s.SatisfiedBy(v) {
+				r = r.Difference(e.Versions)
+			}
+		}
+	}
+
+	return r
+}
+
+// Supported reports whether every version in combination is known and
+// every pair of them works together.
+func (m *Matrix) Supported(combination map[string]semver.Version) bool {
+	for a, av := range combination {
+		if !m.Known(a, av) {
+			return false
+		}
+
+		for b, bv := range combination {
+			if a != b && !m.Allowed(a, av, b).SatisfiedBy(bv) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Newest returns the highest of the available versions of component that
+// is known and works with every version in with, or false if there isn't
+// one.
+func (m *Matrix) Newest(component string, available semver.List, with map[string]semver.Version) (semver.Version, bool) {
+	r := everything
+	for other, v := range with {
+		r = r.Intersect(m.Allowed(other, v, component))
+	}
+
+	l := available.Filter(func(v semver.Version) bool { return m.Known(component, v) })
+	sort.Sort(l)
+
+	return r.BestMatch(l)
+}
+
+// Orphaned returns those of the given versions of component that work with
+// some versions of other, but with none left once the versions in drop are
+// no longer supported. For example, the server versions that lose support
+// if SDK releases below 2.0.0 are dropped are
+//
+//	m.Orphaned("server", servers, "sdk", mustRange("<2.0.0"))
+func (m *Matrix) Orphaned(component string, versions semver.List, other string, drop semver.Range) semver.List {
+	return versions.Filter(func(v semver.Version) bool {
+		r := m.Allowed(component, v, other)
+
+		return !r.IsEmpty() && r.Difference(drop).IsEmpty()
+	})
+}
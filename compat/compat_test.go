@@ -0,0 +1,163 @@
+package compat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+func mustRange(s string) semver.Range {
+	r, err := semver.ParseRange(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+func mustVersion(s string) semver.Version {
+	v, err := semver.ParseVersion(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+func mustList(l ...string) semver.List {
+	r := make(semver.List, len(l))
+	for i, s := range l {
+		r[i] = mustVersion(s)
+	}
+
+	return r
+}
+
+func listStrings(l semver.List) []string {
+	r := make([]string, len(l))
+	for i, v := range l {
+		r[i] = v.String()
+	}
+
+	return r
+}
+
+var matrix = &Matrix{Entries: []Entry{
+	{Component: "server", Versions: mustRange(">=2.0.0 <3.0.0-0"), Supports: map[string]semver.Range{
+		"cli": mustRange(">=1.0.0 <3.0.0-0"),
+		"sdk": mustRange(">=1.0.0 <2.0.0-0"),
+	}},
+	{Component: "server", Versions: mustRange(">=3.0.0 <4.0.0-0"), Supports: map[string]semver.Range{
+		"cli": mustRange(">=2.4.0"),
+		"sdk": mustRange(">=1.8.0"),
+	}},
+	{Component: "server", Versions: mustRange("=3.4.1"), Supports: map[string]semver.Range{
+		"cli": mustRange("<3.2.0"),
+	}},
+	{Component: "sdk", Versions: mustRange(">=2.0.0"), Supports: map[string]semver.Range{
+		"server": mustRange(">=3.2.0 <4.0.0-0"),
+	}},
+	{Component: "cli", Versions: mustRange(">=3.0.0"), Supports: map[string]semver.Range{
+		"server": mustRange(">=3.0.0"),
+	}},
+}}
+
+func TestAllowed(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		component, version, other, out string
+	}{
+		{"server", "3.4.1", "cli", ">=2.4.0 <3.2.0"},
+		{"server", "3.4.2", "cli", ">=2.4.0"},
+		{"server", "2.5.0", "cli", ">=1.0.0 <3.0.0-0"},
+		{"server", "3.1.0", "sdk", ">=1.8.0 <2.0.0"},
+		{"server", "3.2.0", "sdk", ">=1.8.0"},
+		{"sdk", "2.1.0", "server", ">=3.2.0 <4.0.0-0"},
+		{"cli", "2.3.0", "server", "<3.0.0 || >=4.0.0-0"},
+	}
+
+	for _, p := range pairs {
+		r := matrix.Allowed(p.component, mustVersion(p.version), p.other)
+		a.True(r.Equal(mustRange(p.out)), "%s@%s %s: %s", p.component, p.version, p.other, r)
+	}
+}
+
+func TestSupported(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		combination map[string]string
+		ok          bool
+	}{
+		{map[string]string{"server": "3.4.1", "cli": "3.1.0", "sdk": "2.1.0"}, true},
+		{map[string]string{"server": "3.4.1", "cli": "3.2.0"}, false},
+		{map[string]string{"server": "3.4.2", "cli": "3.2.0"}, true},
+		{map[string]string{"server": "3.1.0", "sdk": "2.0.0"}, false},
+		{map[string]string{"server": "3.1.0", "sdk": "1.9.0"}, true},
+		{map[string]string{"server": "2.5.0", "cli": "3.0.0"}, false},
+		{map[string]string{"server": "2.5.0", "cli": "2.9.0", "sdk": "1.2.0"}, true},
+		{map[string]string{"server": "1.0.0", "cli": "1.0.0"}, false},
+		{map[string]string{"server": "3.0.0", "cli": "0.9.0"}, false},
+		{map[string]string{"server": "3.0.0", "dashboard": "0.1.0"}, true},
+		{map[string]string{}, true},
+	}
+
+	for _, p := range pairs {
+		c := map[string]semver.Version{}
+		for k, v := range p.combination {
+			c[k] = mustVersion(v)
+		}
+
+		a.Equal(p.ok, matrix.Supported(c), "%v", p.combination)
+	}
+}
+
+func TestNewest(t *testing.T) {
+	a := assert.New(t)
+
+	clis := mustList("3.3.0", "2.3.0", "3.1.5", "2.4.0", "3.2.0")
+
+	pairs := []struct {
+		with map[string]string
+		out  string
+	}{
+		{map[string]string{"server": "3.4.1"}, "3.1.5"},
+		{map[string]string{"server": "3.4.2"}, "3.3.0"},
+		{map[string]string{"server": "2.5.0"}, "2.4.0"},
+		{map[string]string{"server": "3.0.0", "sdk": "2.0.0"}, "3.3.0"},
+		{map[string]string{}, "3.3.0"},
+	}
+
+	for _, p := range pairs {
+		with := map[string]semver.Version{}
+		for k, v := range p.with {
+			with[k] = mustVersion(v)
+		}
+
+		v, ok := matrix.Newest("cli", clis, with)
+		if a.True(ok, "%v", p.with) {
+			a.Equal(p.out, v.String(), "%v", p.with)
+		}
+	}
+
+	_, ok := matrix.Newest("cli", mustList("3.3.0"), map[string]semver.Version{"server": mustVersion("2.0.0")})
+	a.False(ok)
+
+	v, ok := matrix.Newest("server", mustList("1.9.0", "3.1.0", "3.4.1", "4.0.0"), map[string]semver.Version{"sdk": mustVersion("2.0.0")})
+	if a.True(ok) {
+		a.Equal("3.4.1", v.String())
+	}
+}
+
+func TestOrphaned(t *testing.T) {
+	a := assert.New(t)
+
+	servers := mustList("2.0.0", "2.5.0", "3.0.0", "3.1.0", "3.2.0", "3.4.1")
+
+	a.Equal([]string{"2.0.0", "2.5.0", "3.0.0", "3.1.0"}, listStrings(matrix.Orphaned("server", servers, "sdk", mustRange("<2.0.0"))))
+	a.Equal([]string{}, listStrings(matrix.Orphaned("server", servers, "sdk", mustRange("<1.0.0"))))
+	a.Equal([]string{"2.0.0", "2.5.0"}, listStrings(matrix.Orphaned("server", servers, "cli", mustRange("<3.0.0"))))
+}
@@ -0,0 +1,99 @@
+package semver
+
+type ReleaseType string
+
+const (
+	ReleaseNone       ReleaseType = ""
+	ReleaseMajor      ReleaseType = "major"
+	ReleasePremajor   ReleaseType = "premajor"
+	ReleaseMinor      ReleaseType = "minor"
+	ReleasePreminor   ReleaseType = "preminor"
+	ReleasePatch      ReleaseType = "patch"
+	ReleasePrepatch   ReleaseType = "prepatch"
+	ReleasePrerelease ReleaseType = "prerelease"
+	ReleaseBuild      ReleaseType = "build"
+)
+
+// Diff classifies the change between a and b, in either direction, following
+// node-semver's diff. Versions that differ only in build metadata are
+// ReleaseBuild, and identical ones ReleaseNone.
+func Diff(a, b Version) ReleaseType {
+	c := comparePrecedence(a, b)
+
+	if c == 0 {
+		if a.Compare(b) != 0 {
+			return ReleaseBuild
+		}
+
+		return ReleaseNone
+	}
+
+	high, low := a, b
+	if c < 0 {
+		high, low = b, a
+	}
+
+	if len(low.Prerelease) > 0 && len(high.Prerelease) == 0 {
+		// Going from a prerelease to a release: 1.0.0-1 to anything is
+		// a major release, and otherwise releasing the very version the
+		// prerelease led up to is a minor or patch release.
+		if low.Minor == 0 && low.Patch == 0 {
+			return ReleaseMajor
+		}
+
+		if low.Major == high.Major && low.Minor == high.Minor && low.Patch == high.Patch {
+			if low.Minor != 0 && low.Patch == 0 {
+				return ReleaseMinor
+			}
+
+			return ReleasePatch
+		}
+	}
+
+	pre := len(high.Prerelease) > 0
+
+	switch {
+	case a.Major != b.Major && pre:
+		return ReleasePremajor
+	case a.Major != b.Major:
+		return ReleaseMajor
+	case a.Minor != b.Minor && pre:
+		return ReleasePreminor
+	case a.Minor != b.Minor:
+		return ReleaseMinor
+	case a.Patch != b.Patch && pre:
+		return ReleasePrepatch
+	case a.Patch != b.Patch:
+		return ReleasePatch
+	}
+
+	return ReleasePrerelease
+}
+
+// Compatible reports whether upgrading from one version to another stays
+// within the caret range of the first, as ParseRange would expand "^from".
+// That means 0.x versions only accept changes below their first
+// non-zero part, so ^0.2.3 takes 0.2.9 but not 0.3.0. Prereleases of the
+// first incompatible version, like 2.0.0-rc.1 from 1.2.3, aren't compatible
+// either.
+func Compatible(from, to Version) bool {
+	s, err := partialVersion{
+		hasMajor:   true,
+		hasMinor:   true,
+		hasPatch:   true,
+		major:      from.Major,
+		minor:      from.Minor,
+		patch:      from.Patch,
+		prerelease: from.Prerelease,
+		build:      from.Build,
+	}.comparators(OperatorCaret, nil)
+	if err != nil {
+		return false
+	}
+
+	// The caret range's upper bound, like <2.0.0, admits 2.0.0-rc.1, which
+	// is as breaking as 2.0.0 itself.
+	s[1].Version.Prerelease = []string{"0"}
+
+	return s.SatisfiedBy(to)
+}
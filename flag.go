@@ -0,0 +1,84 @@
+package semver
+
+// VersionFlag is a flag.Value that parses its argument as a Version. It also
+// has the Type method pflag needs, so it works as a pflag.Value too:
+//
+//	var minVersion semver.VersionFlag
+//	flag.Var(&minVersion, "min-version", "oldest supported version")
+type VersionFlag struct {
+	Version Version
+}
+
+func (f *VersionFlag) Set(s string) error {
+	v, err := ParseVersion(s)
+	if err != nil {
+		return err
+	}
+
+	f.Version = v
+
+	return nil
+}
+
+func (f *VersionFlag) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.Version.String()
+}
+
+func (f *VersionFlag) Type() string { return "version" }
+
+func (f *VersionFlag) Get() any { return f.Version }
+
+// RangeFlag is a flag.Value and pflag.Value that parses its argument as a
+// Range. It can be given more than once, in which case a version has to
+// satisfy every one of the ranges, or any one of them if Or is set. The
+// first use replaces whatever Range the flag started with.
+type RangeFlag struct {
+	Range Range
+	Or    bool
+
+	set bool
+}
+
+func (f *RangeFlag) Set(s string) error {
+	r, err := ParseRange(s)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !f.set:
+		f.Range = r
+	case f.Or:
+		f.Range = append(f.Range, r...)
+	default:
+		var l Range
+
+		for _, a := range f.Range {
+			for _, b := range r {
+				l = append(l, append(append(Set(nil), a...), b...))
+			}
+		}
+
+		f.Range = l
+	}
+
+	f.set = true
+
+	return nil
+}
+
+func (f *RangeFlag) String() string {
+	if f == nil || (!f.set && f.Range == nil) {
+		return ""
+	}
+
+	return f.Range.String()
+}
+
+func (f *RangeFlag) Type() string { return "range" }
+
+func (f *RangeFlag) Get() any { return f.Range }
@@ -0,0 +1,237 @@
+// Package flags evaluates feature flags that target clients by version.
+//
+// Flags are loaded from JSON like this:
+//
+//	{
+//	  "new-checkout": {
+//	    "default": "off",
+//	    "rules": [
+//	      {"range": ">=4.2.0 <5 || ^5.1.0-beta", "percentage": 25, "variant": "on"},
+//	      {"range": "<4.2.0", "variant": "legacy"}
+//	    ]
+//	  }
+//	}
+//
+// Rules are tried in order. A rule applies to a client whose version is in
+// its range and whose key, such as a user ID, hashes into its percentage,
+// which defaults to 100. A key always hashes to the same bucket for a flag,
+// so raising a percentage only ever adds clients to a rollout.
+package flags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sort"
+	"sync/atomic"
+
+	"github.com/deoxxa/semver"
+)
+
+type Rule struct {
+	Range      string   `json:"range"`
+	Percentage *float64 `json:"percentage,omitempty"`
+	Variant    string   `json:"variant"`
+
+	matcher *semver.Matcher
+}
+
+type Flag struct {
+	Default string `json:"default"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Set is a collection of flags by name. A Set is never modified once
+// loaded, so it can be read from any number of goroutines.
+type Set map[string]*Flag
+
+func (r *Rule) compile() error {
+	rng, err := semver.ParseRange(r.Range)
+	if err != nil {
+		return fmt.Errorf("range %q: %w", r.Range, err)
+	}
+
+	if r.Percentage != nil && (*r.Percentage < 0 || *r.Percentage > 100) {
+		return fmt.Errorf("percentage %v is not between 0 and 100", *r.Percentage)
+	}
+
+	r.matcher = rng.Compile()
+
+	return nil
+}
+
+func Load(r io.Reader) (Set, error) {
+	var s Set
+
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+
+	if err := d.Decode(&s); err != nil {
+		return nil, err
+	}
+
+	return s.Compile()
+}
+
+// Compile checks every rule in s and returns a copy of s with the rules
+// prepared for evaluation. s itself is left alone, so it's safe to compile
+// a Set that's being read elsewhere. Load and Store.Replace do this
+// themselves; a Set that hasn't been compiled still evaluates correctly,
+// only more slowly.
+func (s Set) Compile() (Set, error) {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	r := make(Set, len(s))
+
+	var errs []error
+
+	for _, name := range names {
+		if s[name] == nil {
+			errs = append(errs, fmt.Errorf("%s: flag is null", name))
+			continue
+		}
+
+		f := *s[name]
+		f.Rules = append([]Rule(nil), f.Rules...)
+
+		for i := range f.Rules {
+			if err := f.Rules[i].compile(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: rule %d: %w", name, i+1, err))
+			}
+		}
+
+		r[name] = &f
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// bucket places key in one of 10000 buckets for the named flag, so that
+// percentages can be given to two decimal places.
+func bucket(flag, key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+
+	return int(h.Sum32() % 10000)
+}
+
+func (r *Rule) matches(flag, key string, v semver.Version) bool {
+	m := r.matcher
+	if m == nil {
+		rng, err := semver.ParseRange(r.Range)
+		if err != nil {
+			return false
+		}
+
+		m = rng.Compile()
+	}
+
+	if !m.SatisfiedBy(v) {
+		return false
+	}
+
+	if r.Percentage == nil {
+		return true
+	}
+
+	return float64(bucket(flag, key)) < *r.Percentage*100
+}
+
+// Result is the outcome of evaluating a flag. Rule is the index of the
+// rule that chose the variant, or -1 if none did and it's the default.
+type Result struct {
+	Variant string
+	Rule    int
+}
+
+// Evaluate returns the variant of the named flag for the client with the
+// given key and version. A flag that doesn't exist evaluates to an empty
+// variant, with ok false.
+func (s Set) Evaluate(flag, key string, v semver.Version) (Result, bool) {
+	f, ok := s[flag]
+	if !ok || f == nil {
+		return Result{Rule: -1}, false
+	}
+
+	for i := range f.Rules {
+		if f.Rules[i].matches(flag, key, v) {
+			return Result{Variant: f.Rules[i].Variant, Rule: i}, true
+		}
+	}
+
+	return Result{Variant: f.Default, Rule: -1}, true
+}
+
+// Store holds the current Set, which can be replaced while other goroutines
+// are evaluating flags. Each evaluation sees either the old Set or the new
+// one, never a mix. The zero value holds an empty Set.
+type Store struct {
+	set atomic.Pointer[Set]
+}
+
+func (st *Store) Current() Set {
+	if s := st.set.Load(); s != nil {
+		return *s
+	}
+
+	return nil
+}
+
+// Replace makes a compiled copy of s the current Set, leaving s alone, so
+// the current Set can be cloned, changed and passed back while it's being
+// read. If s has an invalid rule the current Set is kept.
+func (st *Store) Replace(s Set) error {
+	s, err := s.Compile()
+	if err != nil {
+		return err
+	}
+
+	st.set.Store(&s)
+
+	return nil
+}
+
+func (st *Store) Evaluate(flag, key string, v semver.Version) (Result, bool) {
+	return st.Current().Evaluate(flag, key, v)
+}
+
+// Reload loads a new Set from r and replaces the current one with it. If
+// loading fails the current Set is kept.
+func (st *Store) Reload(r io.Reader) error {
+	s, err := Load(r)
+	if err != nil {
+		return err
+	}
+
+	st.set.Store(&s)
+
+	return nil
+}
+
+func (st *Store) ReloadFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	if err := st.Reload(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
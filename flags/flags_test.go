@@ -0,0 +1,267 @@
+package flags
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+func mustVersion(s string) semver.Version {
+	v, err := semver.ParseVersion(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+func TestEvaluate(t *testing.T) {
+	a := assert.New(t)
+
+	var st Store
+
+	if !a.NoError(st.ReloadFile("testdata/flags.json")) {
+		return
+	}
+
+	on, off := 0, 0
+
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+
+		res, ok := st.Evaluate("new-checkout", key, mustVersion("4.5.0"))
+		a.True(ok)
+
+		again, _ := st.Evaluate("new-checkout", key, mustVersion("5.1.0"))
+		a.Equal(res, again, key)
+
+		switch res.Variant {
+		case "on":
+			a.Equal(0, res.Rule)
+			on++
+		case "off":
+			a.Equal(-1, res.Rule)
+			off++
+		default:
+			a.Fail("unexpected variant", "%s: %s", key, res.Variant)
+		}
+
+		res, _ = st.Evaluate("dark-mode", key, mustVersion("4.5.0"))
+		a.Equal(Result{Variant: "off", Rule: -1}, res, key)
+	}
+
+	a.InDelta(1000, on, 100)
+	a.InDelta(1000, off, 100)
+
+	pairs := []struct {
+		version, variant string
+		rule             int
+	}{
+		{"3.9.9", "legacy", 1},
+		{"4.1.0", "off", -1},
+		{"5.0.0", "off", -1},
+		{"5.1.0-beta.2", "on", 0},
+		{"5.9.0", "on", 0},
+		{"6.0.0", "off", -1},
+	}
+
+	// user-3 falls in the first half of new-checkout's buckets.
+	a.Less(bucket("new-checkout", "user-3"), 5000)
+
+	for _, p := range pairs {
+		res, ok := st.Evaluate("new-checkout", "user-3", mustVersion(p.version))
+		a.True(ok)
+		a.Equal(Result{Variant: p.variant, Rule: p.rule}, res, p.version)
+	}
+
+	res, ok := st.Evaluate("missing", "user-3", mustVersion("1.0.0"))
+	a.False(ok)
+	a.Equal(Result{Rule: -1}, res)
+}
+
+func TestPercentageGrows(t *testing.T) {
+	a := assert.New(t)
+
+	at := func(pct float64) Set {
+		s, err := Load(strings.NewReader(fmt.Sprintf(`{"f": {"default": "off", "rules": [{"range": "*", "percentage": %v, "variant": "on"}]}}`, pct)))
+		if err != nil {
+			panic(err)
+		}
+
+		return s
+	}
+
+	small, large := at(10), at(60)
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("k%d", i)
+
+		if r, _ := small.Evaluate("f", key, mustVersion("1.0.0")); r.Variant == "on" {
+			r, _ = large.Evaluate("f", key, mustVersion("1.0.0"))
+			a.Equal("on", r.Variant, key)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := Load(strings.NewReader(`{
+		"a": {"rules": [{"range": ">=1.x.y", "variant": "on"}]},
+		"b": {"rules": [{"range": "*", "percentage": 101, "variant": "on"}]},
+		"c": null
+	}`))
+	if a.Error(err) {
+		lines := strings.Split(err.Error(), "\n")
+		if a.Len(lines, 3) {
+			a.True(strings.HasPrefix(lines[0], "a: rule 1: range \">=1.x.y\": "), lines[0])
+			a.Equal("b: rule 1: percentage 101 is not between 0 and 100", lines[1])
+			a.Equal("c: flag is null", lines[2])
+		}
+	}
+
+	_, err = Load(strings.NewReader(`{"a": {"rules": [], "extra": 1}}`))
+	a.Error(err)
+}
+
+func TestReload(t *testing.T) {
+	a := assert.New(t)
+
+	var st Store
+
+	res, ok := st.Evaluate("new-checkout", "user-1", mustVersion("4.0.0"))
+	a.False(ok)
+	a.Equal(Result{Rule: -1}, res)
+
+	a.NoError(st.ReloadFile("testdata/flags.json"))
+
+	var wg sync.WaitGroup
+
+	stop := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				res, ok := st.Evaluate("new-checkout", "user-1", mustVersion("4.0.0"))
+				if !ok || (res.Variant != "off" && res.Variant != "on") {
+					a.Fail("unexpected result", "%v %v", res, ok)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		name := "testdata/flags.json"
+		if i%2 == 1 {
+			name = "testdata/flags-v2.json"
+		}
+
+		a.NoError(st.ReloadFile(name))
+	}
+
+	close(stop)
+	wg.Wait()
+
+	a.Error(st.Reload(strings.NewReader(`{"new-checkout": {"rules": [{"range": "nope"}]}}`)))
+
+	res, _ = st.Evaluate("new-checkout", "user-1", mustVersion("4.2.0"))
+	a.Equal(Result{Variant: "on", Rule: 0}, res, "failed reload keeps the last good set")
+	a.Len(st.Current(), 1)
+}
+
+func TestUncompiled(t *testing.T) {
+	a := assert.New(t)
+
+	s := Set{
+		"x":    {Default: "off", Rules: []Rule{{Range: ">=2.0.0", Variant: "on"}}},
+		"bad":  {Default: "off", Rules: []Rule{{Range: "nope", Variant: "on"}}},
+		"null": nil,
+	}
+
+	res, ok := s.Evaluate("x", "k", mustVersion("2.1.0"))
+	a.True(ok)
+	a.Equal(Result{Variant: "on", Rule: 0}, res)
+
+	res, _ = s.Evaluate("x", "k", mustVersion("1.0.0"))
+	a.Equal(Result{Variant: "off", Rule: -1}, res)
+
+	res, _ = s.Evaluate("bad", "k", mustVersion("1.0.0"))
+	a.Equal(Result{Variant: "off", Rule: -1}, res)
+
+	res, ok = s.Evaluate("null", "k", mustVersion("1.0.0"))
+	a.False(ok)
+	a.Equal(Result{Rule: -1}, res)
+
+	var st Store
+
+	a.Error(st.Replace(s))
+	a.Nil(st.Current())
+
+	a.NoError(st.Replace(Set{"x": {Rules: []Rule{{Range: "*", Variant: "on"}}}}))
+
+	res, ok = st.Evaluate("x", "k", mustVersion("1.0.0"))
+	a.True(ok)
+	a.Equal(Result{Variant: "on", Rule: 0}, res)
+}
+
+func TestReplaceConcurrent(t *testing.T) {
+	a := assert.New(t)
+
+	var st Store
+
+	a.NoError(st.ReloadFile("testdata/flags.json"))
+
+	var wg sync.WaitGroup
+
+	stop := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				if _, ok := st.Evaluate("new-checkout", "user-1", mustVersion("4.5.0")); !ok {
+					a.Fail("new-checkout is missing")
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 200; i++ {
+		next := maps.Clone(st.Current())
+		next[fmt.Sprintf("flag-%d", i%5)] = &Flag{Default: "off", Rules: []Rule{{Range: ">=1.0.0", Variant: "on"}}}
+
+		a.NoError(st.Replace(next))
+	}
+
+	close(stop)
+	wg.Wait()
+
+	a.Len(st.Current(), 7)
+}
@@ -0,0 +1,313 @@
+// Package gomod reads go.mod and go.sum files, with module versions parsed
+// into semver.Version values, and suggests upgrades for required modules.
+package gomod
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+
+	"github.com/deoxxa/semver"
+)
+
+type Require struct {
+	Path     string
+	Version  semver.Version
+	Indirect bool
+}
+
+type Exclude struct {
+	Path    string
+	Version semver.Version
+}
+
+// Replace replaces Old with New. Old.Version is nil when every version of
+// Old.Path is replaced, and New.Version is nil when New.Path is a directory.
+type Replace struct {
+	Old, New ModuleRef
+}
+
+type ModuleRef struct {
+	Path    string
+	Version *semver.Version
+}
+
+type File struct {
+	Module  string
+	Go      string
+	Require []Require
+	Exclude []Exclude
+	Replace []Replace
+}
+
+func parseVersion(path, v string) (semver.Version, error) {
+	r, err := semver.ParseVersion(v)
+	if err != nil {
+		return r, fmt.Errorf("%s@%s: %w", path, v, err)
+	}
+
+	return r, nil
+}
+
+func Parse(name string, data []byte) (*File, error) {
+	mf, err := modfile.Parse(name, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+
+	if mf.Module != nil {
+		f.Module = mf.Module.Mod.Path
+	}
+
+	if mf.Go != nil {
+		f.Go = mf.Go.Version
+	}
+
+	for _, r := range mf.Require {
+		v, err := parseVersion(r.Mod.Path, r.Mod.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		f.Require = append(f.Require, Require{Path: r.Mod.Path, Version: v, Indirect: r.Indirect})
+	}
+
+	for _, e := range mf.Exclude {
+		v, err := parseVersion(e.Mod.Path, e.Mod.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		f.Exclude = append(f.Exclude, Exclude{Path: e.Mod.Path, Version: v})
+	}
+
+	for _, r := range mf.Replace {
+		var rep Replace
+
+		for _, p := range []struct {
+			ref *ModuleRef
+			mod module.Version
+		}{{&rep.Old, r.Old}, {&rep.New, r.New}} {
+			p.ref.Path = p.mod.Path
+
+			if p.mod.Version != "" {
+				v, err := parseVersion(p.mod.Path, p.mod.Version)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+
+				p.ref.Version = &v
+			}
+		}
+
+		f.Replace = append(f.Replace, rep)
+	}
+
+	return &f, nil
+}
+
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(path, data)
+}
+
+// Sum is a line of a go.sum file. GoMod is set for lines that hash only a
+// module's go.mod file, which are written with a "/go.mod" version suffix.
+type Sum struct {
+	Path    string
+	Version semver.Version
+	GoMod   bool
+	Hash    string
+}
+
+func ParseSum(r io.Reader) ([]Sum, error) {
+	var l []Sum
+
+	s := bufio.NewScanner(r)
+
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected module, version and hash", n)
+		}
+
+		ver, goMod := strings.CutSuffix(fields[1], "/go.mod")
+
+		v, err := parseVersion(fields[0], ver)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		l = append(l, Sum{Path: fields[0], Version: v, GoMod: goMod, Hash: fields[2]})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func ReadSumFile(path string) ([]Sum, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l, err := ParseSum(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return l, nil
+}
+
+// Suggestion is an upgrade from one version of a required module to
+// another. A major upgrade of a module with a major version suffix moves to
+// a different module path, NewPath, like example.com/mod/v3 in place of
+// example.com/mod/v2; otherwise NewPath is the same as Path.
+type Suggestion struct {
+	Path    string
+	NewPath string
+	From    semver.Version
+	To      semver.Version
+	Type    semver.ReleaseType
+}
+
+// Upgrades holds suggestions by their type, ReleasePatch, ReleaseMinor or
+// ReleaseMajor, each sorted by module path.
+type Upgrades map[semver.ReleaseType][]Suggestion
+
+func (f *File) replaced(path string, v semver.Version) bool {
+	for _, r := range f.Replace {
+		if r.Old.Path == path && (r.Old.Version == nil || r.Old.Version.Compare(v) == 0) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *File) excluded(path string, v semver.Version) bool {
+	for _, e := range f.Exclude {
+		if e.Path == path && e.Version.Compare(v) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// candidates returns the releases of path in available that the module
+// path's major version suffix allows, and that aren't excluded.
+func (f *File) candidates(path, pathMajor string, available semver.List) semver.List {
+	var l semver.List
+
+	for _, v := range available {
+		if len(v.Prerelease) > 0 || f.excluded(path, v) || !module.MatchPathMajor("v"+v.String(), pathMajor) {
+			continue
+		}
+
+		l = append(l, v)
+	}
+
+	return l
+}
+
+func pathMajorNumber(pathMajor string) int {
+	if len(pathMajor) < 2 {
+		return 1
+	}
+
+	n, err := strconv.Atoi(pathMajor[2:])
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// Upgrades suggests the newest patch, minor and major release of each
+// required module, from the versions in available. Versions are only
+// suggested if they're allowed by the module path's /vN suffix. So newer
+// major versions come from the other module paths in available that share
+// the module's prefix, unless the module uses +incompatible versions.
+// Prereleases, excluded versions and replaced modules are skipped.
+func (f *File) Upgrades(available map[string]semver.List) Upgrades {
+	u := Upgrades{}
+
+	for _, r := range f.Require {
+		if f.replaced(r.Path, r.Version) {
+			continue
+		}
+
+		prefix, pathMajor, ok := module.SplitPathVersion(r.Path)
+		if !ok {
+			continue
+		}
+
+		best := map[semver.ReleaseType]Suggestion{}
+
+		consider := func(newPath string, v semver.Version) {
+			if v.Compare(r.Version) <= 0 {
+				return
+			}
+
+			t := semver.Diff(r.Version, v)
+			switch t {
+			case semver.ReleasePatch, semver.ReleaseMinor, semver.ReleaseMajor:
+			default:
+				return
+			}
+
+			if b, ok := best[t]; ok && b.To.Compare(v) >= 0 {
+				return
+			}
+
+			best[t] = Suggestion{Path: r.Path, NewPath: newPath, From: r.Version, To: v, Type: t}
+		}
+
+		for _, v := range f.candidates(r.Path, pathMajor, available[r.Path]) {
+			consider(r.Path, v)
+		}
+
+		for path, l := range available {
+			p, m, ok := module.SplitPathVersion(path)
+			if !ok || p != prefix || path == r.Path || pathMajorNumber(m) <= pathMajorNumber(pathMajor) {
+				continue
+			}
+
+			for _, v := range f.candidates(path, m, l) {
+				consider(path, v)
+			}
+		}
+
+		for t, s := range best {
+			u[t] = append(u[t], s)
+		}
+	}
+
+	for _, l := range u {
+		sort.Slice(l, func(i, j int) bool { return l[i].Path < l[j].Path })
+	}
+
+	return u
+}
@@ -0,0 +1,142 @@
+package gomod
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+func parseList(a *assert.Assertions, l ...string) semver.List {
+	r := make(semver.List, len(l))
+
+	for i, s := range l {
+		v, err := semver.ParseVersion(s)
+		a.NoError(err, s)
+
+		r[i] = v
+	}
+
+	return r
+}
+
+func TestReadFile(t *testing.T) {
+	a := assert.New(t)
+
+	f, err := ReadFile("testdata/go.mod")
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("example.com/service", f.Module)
+	a.Equal("1.22", f.Go)
+
+	var l []string
+	for _, r := range f.Require {
+		l = append(l, r.Path+"@"+r.Version.String())
+	}
+
+	a.Equal([]string{
+		"example.com/lib@1.2.3",
+		"example.com/tool/v2@2.1.0",
+		"gopkg.in/yaml.v2@2.2.8",
+		"example.com/old@2.0.0+incompatible",
+		"example.com/replaced@1.0.0",
+		"example.com/pinned@0.3.1",
+		"golang.org/x/text@0.0.0-20170915032832-14c0d48ead0c",
+	}, l)
+	a.True(f.Require[5].Indirect)
+	a.False(f.Require[0].Indirect)
+
+	a.Equal([]Exclude{{Path: "example.com/lib", Version: semver.Version{Major: 1, Minor: 2, Patch: 5}}}, f.Exclude)
+
+	if a.Len(f.Replace, 2) {
+		a.Equal("example.com/replaced", f.Replace[0].Old.Path)
+		a.Nil(f.Replace[0].Old.Version)
+		a.Equal("../replaced", f.Replace[0].New.Path)
+		a.Nil(f.Replace[0].New.Version)
+		a.Equal("0.3.1", f.Replace[1].Old.Version.String())
+		a.Equal("example.com/fork", f.Replace[1].New.Path)
+		a.Equal("0.3.2", f.Replace[1].New.Version.String())
+	}
+
+	_, err = Parse("bad.mod", []byte("module x\n\nrequire example.com/a latest\n"))
+	a.Error(err)
+
+	_, err = ReadFile("testdata/missing.mod")
+	a.Error(err)
+}
+
+func TestReadSumFile(t *testing.T) {
+	a := assert.New(t)
+
+	l, err := ReadSumFile("testdata/go.sum")
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Len(l, 4)
+	a.Equal(Sum{Path: "example.com/lib", Version: semver.Version{Major: 1, Minor: 2, Patch: 3}, Hash: "h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}, l[0])
+	a.True(l[1].GoMod)
+	a.Equal("example.com/tool/v2", l[2].Path)
+	a.Equal("0.0.0-20170915032832-14c0d48ead0c", l[3].Version.String())
+
+	_, err = ParseSum(strings.NewReader("example.com/lib v1.2.3\n"))
+	a.EqualError(err, "line 1: expected module, version and hash")
+
+	_, err = ParseSum(strings.NewReader("example.com/lib latest h1:x=\n"))
+	a.EqualError(err, "line 1: example.com/lib@latest: invalid major version")
+}
+
+func TestUpgrades(t *testing.T) {
+	a := assert.New(t)
+
+	f, err := ReadFile("testdata/go.mod")
+	if !a.NoError(err) {
+		return
+	}
+
+	u := f.Upgrades(map[string]semver.List{
+		"example.com/lib":      parseList(a, "v1.2.3", "v1.2.4", "v1.2.5", "v1.3.0", "v1.4.0-rc.1", "v1.3.1", "v1.2.2"),
+		"example.com/lib/v2":   parseList(a, "v2.0.0", "v2.1.0"),
+		"example.com/lib/v3":   parseList(a, "v3.0.0-beta.1"),
+		"example.com/tool/v2":  parseList(a, "v2.1.0", "v2.1.1", "v3.0.0"),
+		"example.com/tool/v3":  parseList(a, "v3.0.0", "v3.2.0"),
+		"example.com/tool":     parseList(a, "v1.9.0"),
+		"gopkg.in/yaml.v2":     parseList(a, "v2.2.8", "v2.4.0"),
+		"gopkg.in/yaml.v3":     parseList(a, "v3.0.1"),
+		"example.com/old":      parseList(a, "v2.0.0+incompatible", "v3.1.0+incompatible", "v2.0.1"),
+		"example.com/replaced": parseList(a, "v1.5.0"),
+		"example.com/pinned":   parseList(a, "v0.3.2", "v0.4.0"),
+	})
+
+	dump := func(t semver.ReleaseType) []string {
+		var l []string
+		for _, s := range u[t] {
+			l = append(l, s.Path+" "+s.From.String()+" -> "+s.NewPath+" "+s.To.String())
+		}
+
+		return l
+	}
+
+	a.Equal([]string{
+		"example.com/lib 1.2.3 -> example.com/lib 1.2.4",
+		"example.com/tool/v2 2.1.0 -> example.com/tool/v2 2.1.1",
+	}, dump(semver.ReleasePatch))
+
+	a.Equal([]string{
+		"example.com/lib 1.2.3 -> example.com/lib 1.3.1",
+		"gopkg.in/yaml.v2 2.2.8 -> gopkg.in/yaml.v2 2.4.0",
+	}, dump(semver.ReleaseMinor))
+
+	a.Equal([]string{
+		"example.com/lib 1.2.3 -> example.com/lib/v2 2.1.0",
+		"example.com/old 2.0.0+incompatible -> example.com/old 3.1.0+incompatible",
+		"example.com/tool/v2 2.1.0 -> example.com/tool/v3 3.2.0",
+		"gopkg.in/yaml.v2 2.2.8 -> gopkg.in/yaml.v3 3.0.1",
+	}, dump(semver.ReleaseMajor))
+
+	a.Len(u, 3)
+}
@@ -0,0 +1,19 @@
+module example.com/service
+
+go 1.22
+
+require (
+	example.com/lib v1.2.3
+	example.com/tool/v2 v2.1.0
+	gopkg.in/yaml.v2 v2.2.8
+	example.com/old v2.0.0+incompatible
+	example.com/replaced v1.0.0
+	example.com/pinned v0.3.1 // indirect
+	golang.org/x/text v0.0.0-20170915032832-14c0d48ead0c
+)
+
+exclude example.com/lib v1.2.5
+
+replace example.com/replaced => ../replaced
+
+replace example.com/pinned v0.3.1 => example.com/fork v0.3.2
@@ -0,0 +1,356 @@
+// Package interop converts versions and ranges to and from the types used by
+// github.com/Masterminds/semver/v3 and golang.org/x/mod/semver, to make it
+// possible to move code over to this package a piece at a time.
+//
+// Where the other side can't express exactly the same thing, the conversion
+// still returns its closest equivalent, along with a Loss for each thing
+// that behaves differently.
+package interop
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	mm "github.com/Masterminds/semver/v3"
+	modsemver "golang.org/x/mod/semver"
+
+	"github.com/deoxxa/semver"
+)
+
+// Loss describes part of a conversion that doesn't carry over exactly.
+type Loss struct {
+	Input  string
+	Reason string
+}
+
+func (l Loss) String() string {
+	return l.Input + ": " + l.Reason
+}
+
+func VersionToMasterminds(v semver.Version) (*mm.Version, error) {
+	if v.Major < 0 || v.Minor < 0 || v.Patch < 0 {
+		return nil, fmt.Errorf("%s: negative version number", v)
+	}
+
+	return mm.New(uint64(v.Major), uint64(v.Minor), uint64(v.Patch), strings.Join(v.Prerelease, "."), strings.Join(v.Build, ".")), nil
+}
+
+func VersionFromMasterminds(v *mm.Version) (semver.Version, error) {
+	if v == nil {
+		return semver.Version{}, fmt.Errorf("nil version")
+	}
+
+	if v.Major() > math.MaxInt64 || v.Minor() > math.MaxInt64 || v.Patch() > math.MaxInt64 {
+		return semver.Version{}, fmt.Errorf("%s: version number out of range", v)
+	}
+
+	r := semver.Version{
+		Major: int64(v.Major()),
+		Minor: int64(v.Minor()),
+		Patch: int64(v.Patch()),
+	}
+
+	if v.Prerelease() != "" {
+		r.Prerelease = strings.Split(v.Prerelease(), ".")
+	}
+
+	if v.Metadata() != "" {
+		r.Build = strings.Split(v.Metadata(), ".")
+	}
+
+	return r, nil
+}
+
+// RangeToMasterminds converts r into Constraints that accept the same
+// versions. Since a Range doesn't treat prereleases specially, the
+// Constraints have IncludePrerelease set; it isn't kept by their String or
+// MarshalText methods.
+func RangeToMasterminds(r semver.Range) (*mm.Constraints, error) {
+	sets := r.Compile().Range()
+
+	l := make([]string, len(sets))
+
+	for i, s := range sets {
+		if len(s) == 0 {
+			l[i] = ">=0.0.0-0"
+		} else {
+			l[i] = s.String()
+		}
+	}
+
+	c, err := mm.NewConstraint(strings.Join(l, " || "))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sets, err)
+	}
+
+	c.IncludePrerelease = true
+
+	return c, nil
+}
+
+// RangeFromMasterminds converts c into a Range. Unless c has
+// IncludePrerelease set, Masterminds leaves out prereleases from any group
+// of constraints that doesn't mention one, which a Range can't do; each such
+// group is reported as a Loss.
+func RangeFromMasterminds(c *mm.Constraints) (semver.Range, []Loss, error) {
+	if c == nil {
+		return nil, nil, fmt.Errorf("nil constraints")
+	}
+
+	var r semver.Range
+	var losses []Loss
+
+	for _, group := range strings.Split(c.String(), " || ") {
+		var and []string
+		var not semver.Range
+		var pre bool
+
+		for _, tok := range strings.Fields(group) {
+			t, err := parseToken(tok)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			a, n, loss, err := t.translate()
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", tok, err)
+			}
+
+			and = append(and, a...)
+
+			if n != "" {
+				x, err := semver.ParseRange(n)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s: %w", tok, err)
+				}
+
+				not = append(not, x...)
+			}
+
+			if loss != "" {
+				losses = append(losses, Loss{Input: tok, Reason: loss})
+			}
+
+			pre = pre || t.pre != ""
+		}
+
+		g, err := semver.ParseRange(strings.Join(and, " "))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", group, err)
+		}
+
+		if len(not) > 0 {
+			g = g.Difference(not)
+		}
+
+		if !pre && !c.IncludePrerelease {
+			losses = append(losses, Loss{Input: group, Reason: "prereleases satisfy the range, but Masterminds excludes them"})
+		}
+
+		r = append(r, g...)
+	}
+
+	return r.Compile().Range(), losses, nil
+}
+
+// token is a single Masterminds constraint, like "~>1.2" or "!=3.x".
+type token struct {
+	op    string
+	nums  [3]uint64
+	fixed int
+	pre   string
+}
+
+var operators = []string{"!=", ">=", "=>", "<=", "=<", "~>", ">", "<", "=", "~", "^"}
+
+func parseToken(s string) (token, error) {
+	var t token
+
+	rest := s
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			t.op, rest = op, rest[len(op):]
+			break
+		}
+	}
+
+	rest = strings.TrimPrefix(rest, "v")
+
+	if i := strings.IndexByte(rest, '+'); i != -1 {
+		rest = rest[:i]
+	}
+
+	if i := strings.IndexByte(rest, '-'); i != -1 {
+		rest, t.pre = rest[:i], rest[i+1:]
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return t, fmt.Errorf("%s: can't parse constraint", s)
+	}
+
+	t.fixed = len(parts)
+
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			t.fixed = i
+			break
+		}
+
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return t, fmt.Errorf("%s: %w", s, err)
+		}
+
+		if n >= math.MaxInt64 {
+			return t, fmt.Errorf("%s: version number out of range", s)
+		}
+
+		t.nums[i] = n
+	}
+
+	for i := t.fixed; i < 3; i++ {
+		t.nums[i] = 0
+	}
+
+	return t, nil
+}
+
+// base is the version Masterminds compares against, with any wildcards
+// replaced by zero.
+func (t token) base() string {
+	s := fmt.Sprintf("%d.%d.%d", t.nums[0], t.nums[1], t.nums[2])
+	if t.pre != "" {
+		s += "-" + t.pre
+	}
+
+	return s
+}
+
+// below returns the lowest version of the next major, minor or patch
+// release after t, which ends the wildcard or caret range t describes.
+func (t token) below(part int) string {
+	n := t.nums
+	n[part]++
+
+	for i := part + 1; i < 3; i++ {
+		n[i] = 0
+	}
+
+	return fmt.Sprintf("%d.%d.%d-0", n[0], n[1], n[2])
+}
+
+// translate returns the comparators t stands for, and a range of versions
+// it excludes if it's a wildcard !=. The comparators only ever use complete
+// versions, and upper bounds end at "-0" so that prereleases of the next
+// release are left out, as Masterminds leaves them out. This follows what
+// Masterminds does rather than what its documentation says where they
+// disagree, as with "^*" only matching 0.0.0.
+func (t token) translate() ([]string, string, string, error) {
+	b := t.base()
+
+	switch t.op {
+	case "", "=":
+		if t.fixed == 3 {
+			return []string{"=" + b}, "", "", nil
+		}
+
+		return t.tilde(), "", "", nil
+	case "~", "~>":
+		return t.tilde(), "", "", nil
+	case "^":
+		switch {
+		case t.nums[0] > 0 || t.fixed == 1:
+			return []string{">=" + b, "<" + t.below(0)}, "", "", nil
+		case t.nums[1] > 0 || t.fixed == 2:
+			return []string{">=" + b, "<" + t.below(1)}, "", "", nil
+		}
+
+		return []string{">=" + b, "<" + t.below(2)}, "", "", nil
+	case ">":
+		switch t.fixed {
+		case 1:
+			return []string{">=" + t.below(0)}, "", "", nil
+		case 2:
+			return []string{">=" + t.below(1)}, "", "", nil
+		}
+
+		return []string{">" + b}, "", "", nil
+	case "<":
+		return []string{"<" + b}, "", "", nil
+	case ">=", "=>":
+		return []string{">=" + b}, "", "", nil
+	case "<=", "=<":
+		switch t.fixed {
+		case 0:
+			return []string{"<0.1.0-0"}, "", "", nil
+		case 1:
+			return []string{"<" + t.below(0)}, "", "", nil
+		case 2:
+			return []string{"<" + t.below(1)}, "", "", nil
+		}
+
+		return []string{"<=" + b}, "", "", nil
+	case "!=":
+		switch t.fixed {
+		case 1:
+			return nil, fmt.Sprintf(">=%d.0.0-0 <%s", t.nums[0], t.below(0)), "", nil
+		case 2:
+			return nil, fmt.Sprintf(">=%d.%d.0-0 <%s", t.nums[0], t.nums[1], t.below(1)), "Masterminds still allows prereleases in this line", nil
+		}
+
+		return []string{"!=" + b}, "", "", nil
+	}
+
+	return nil, "", "", fmt.Errorf("unknown operator %q", t.op)
+}
+
+func (t token) tilde() []string {
+	b := t.base()
+
+	switch {
+	case t.nums == [3]uint64{} && (t.fixed == 0 || t.fixed == 3):
+		return []string{">=" + b}
+	case t.fixed == 1:
+		return []string{">=" + b, "<" + t.below(0)}
+	}
+
+	return []string{">=" + b, "<" + t.below(1)}
+}
+
+// VersionToGo returns v in the canonical form golang.org/x/mod/semver uses,
+// like "v1.2.3-rc.1". Canonical versions don't have build metadata, so if v
+// has any it's dropped and reported as a Loss.
+func VersionToGo(v semver.Version) (string, []Loss, error) {
+	var losses []Loss
+
+	if len(v.Build) > 0 {
+		losses = append(losses, Loss{Input: v.String(), Reason: "build metadata is dropped"})
+	}
+
+	s := "v" + semver.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: v.Prerelease}.String()
+
+	if !modsemver.IsValid(s) {
+		return "", nil, fmt.Errorf("%s: not a valid Go module version", s)
+	}
+
+	return s, losses, nil
+}
+
+// VersionFromGo parses s as golang.org/x/mod/semver would, which means it
+// needs a "v" prefix and accepts shorthands like "v1.2".
+func VersionFromGo(s string) (semver.Version, error) {
+	c := modsemver.Canonical(s)
+	if c == "" {
+		return semver.Version{}, fmt.Errorf("%s: not a valid Go module version", s)
+	}
+
+	v, err := semver.ParseVersion(c[1:] + modsemver.Build(s))
+	if err != nil {
+		return v, fmt.Errorf("%s: %w", s, err)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,237 @@
+package interop
+
+import (
+	"testing"
+
+	mm "github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+var versions = []string{
+	"0.0.0-alpha", "0.0.0", "0.0.1-rc.1", "0.0.1", "0.0.2", "0.1.0-0", "0.1.0", "0.1.5", "0.2.0",
+	"1.0.0-alpha", "1.0.0", "1.0.0+build.5", "1.1.0", "1.2.0-beta.2", "1.2.0", "1.2.3", "1.2.9",
+	"1.3.0-rc.1", "1.3.0", "1.9.9", "2.0.0-0", "2.0.0-rc.1", "2.0.0", "2.4.1", "3.0.0", "3.1.0-pre", "10.0.0",
+}
+
+func parseVersions(a *assert.Assertions) []semver.Version {
+	l := make([]semver.Version, len(versions))
+
+	for i, s := range versions {
+		v, err := semver.ParseVersion(s)
+		a.NoError(err)
+
+		l[i] = v
+	}
+
+	return l
+}
+
+func TestRangeFromMasterminds(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		in, out string
+		lossy   bool
+	}{
+		{"*", ">=0.0.0", false},
+		{"1.2.3", "=1.2.3", false},
+		{"=1.2", ">=1.2.0 <1.3.0-0", false},
+		{"1.x", ">=1.0.0 <2.0.0-0", false},
+		{"~1.2.3", ">=1.2.3 <1.3.0-0", false},
+		{"~>1.2", ">=1.2.0 <1.3.0-0", false},
+		{"~1", ">=1.0.0 <2.0.0-0", false},
+		{"~0.0.0", ">=0.0.0", false},
+		{"^1.2.3", ">=1.2.3 <2.0.0-0", false},
+		{"^0.1.5", ">=0.1.5 <0.2.0-0", false},
+		{"^0.0.1", ">=0.0.1 <0.0.2-0", false},
+		{"^0.0", ">=0.0.0 <0.1.0-0", false},
+		{"^0", ">=0.0.0 <1.0.0-0", false},
+		{"^*", ">=0.0.0 <0.0.1-0", false},
+		{">1.2", ">=1.3.0-0", false},
+		{">1", ">=2.0.0-0", false},
+		{">1.2.3", ">1.2.3", false},
+		{"<=1.2", "<1.3.0-0", false},
+		{"=<1.2.3", "<=1.2.3", false},
+		{"=>1.2.3, <2", ">=1.2.3 <2.0.0", false},
+		{"1.2 - 1.4", ">=1.2.0 <1.5.0-0", false},
+		{"1.2.0 - 1.4.5", ">=1.2.0 <=1.4.5", false},
+		{"!=1.2.3", "<1.2.3 || >1.2.3", false},
+		{"!=1.x", "<1.0.0-0 || >=2.0.0-0", false},
+		{">=1.0.0 !=1.2.x", ">=1.0.0 <1.2.0-0 || >=1.3.0-0", true},
+		{"<1.0.0-beta || >=2.0.0-rc.1", "<1.0.0-beta || >=2.0.0-rc.1", false},
+		{"^1.2 || ^3", ">=1.2.0 <2.0.0-0 || >=3.0.0 <4.0.0-0", false},
+	}
+
+	vl := parseVersions(a)
+
+	for _, p := range pairs {
+		c, err := mm.NewConstraint(p.in)
+		if !a.NoError(err, p.in) {
+			continue
+		}
+
+		c.IncludePrerelease = true
+
+		r, losses, err := RangeFromMasterminds(c)
+		if !a.NoError(err, p.in) {
+			continue
+		}
+
+		a.Equal(p.out, r.String(), p.in)
+		a.Equal(p.lossy, len(losses) > 0, "%s: %v", p.in, losses)
+
+		for i, v := range vl {
+			if p.lossy && len(v.Prerelease) > 0 {
+				continue
+			}
+
+			a.Equal(c.Check(mm.MustParse(versions[i])), r.SatisfiedBy(v), "%s: %s", p.in, versions[i])
+		}
+	}
+}
+
+func TestRangeFromMastermindsPrerelease(t *testing.T) {
+	a := assert.New(t)
+
+	c, err := mm.NewConstraint("^1.2 || >=2.0.0-rc.1 <3")
+	a.NoError(err)
+
+	r, losses, err := RangeFromMasterminds(c)
+	a.NoError(err)
+	a.Equal(">=1.2.0 <2.0.0-0 || >=2.0.0-rc.1 <3.0.0", r.String())
+	a.Equal([]Loss{{Input: "^1.2", Reason: "prereleases satisfy the range, but Masterminds excludes them"}}, losses)
+
+	for i, v := range parseVersions(a) {
+		if v.Major == 1 && len(v.Prerelease) > 0 {
+			continue
+		}
+
+		a.Equal(c.Check(mm.MustParse(versions[i])), r.SatisfiedBy(v), versions[i])
+	}
+}
+
+func TestRangeToMasterminds(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		in, out string
+	}{
+		{"", ">=0.0.0-0"},
+		{"^1.2.3", ">=1.2.3 <2.0.0"},
+		{"1.2.x || >=3.0.0-0", ">=1.2.0 <1.3.0 || >=3.0.0-0"},
+		{"!=1.2.3 <2", "<1.2.3 || >1.2.3 <2.0.0"},
+		{"1.0.0 - 1.2.5", ">=1.0.0 <=1.2.5"},
+		{"=1.2.3+build", "=1.2.3+build"},
+		{"<1.0.0 >2.0.0", "<0.0.0-0"},
+	}
+
+	vl := parseVersions(a)
+
+	for _, p := range pairs {
+		r, err := semver.ParseRange(p.in)
+		if !a.NoError(err, p.in) {
+			continue
+		}
+
+		c, err := RangeToMasterminds(r)
+		if !a.NoError(err, p.in) {
+			continue
+		}
+
+		a.Equal(p.out, c.String(), p.in)
+		a.True(c.IncludePrerelease)
+
+		for i, v := range vl {
+			a.Equal(r.SatisfiedBy(v), c.Check(mm.MustParse(versions[i])), "%s: %s", p.in, versions[i])
+		}
+
+		back, losses, err := RangeFromMasterminds(c)
+		a.NoError(err, p.in)
+		a.Empty(losses, p.in)
+		a.True(back.Equal(r), "%s: %s", p.in, back)
+	}
+}
+
+func TestVersionMasterminds(t *testing.T) {
+	a := assert.New(t)
+
+	for _, s := range versions {
+		v, err := semver.ParseVersion(s)
+		a.NoError(err)
+
+		m, err := VersionToMasterminds(v)
+		if !a.NoError(err, s) {
+			continue
+		}
+
+		a.Equal(s, m.String())
+		a.Equal(0, m.Compare(mm.MustParse(s)), s)
+
+		back, err := VersionFromMasterminds(m)
+		a.NoError(err, s)
+		a.Equal(v, back, s)
+	}
+
+	v, err := VersionFromMasterminds(mm.MustParse("v1.2"))
+	a.NoError(err)
+	a.Equal("1.2.0", v.String())
+
+	_, err = VersionFromMasterminds(mm.New(1<<63, 0, 0, "", ""))
+	a.EqualError(err, "9223372036854775808.0.0: version number out of range")
+
+	_, err = VersionToMasterminds(semver.Version{Major: -1})
+	a.Error(err)
+}
+
+func TestVersionGo(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		in, out string
+		lossy   bool
+	}{
+		{"1.2.3", "v1.2.3", false},
+		{"1.0.0-rc.1", "v1.0.0-rc.1", false},
+		{"2.0.0+incompatible", "v2.0.0", true},
+		{"0.0.0-20240101120000-abcdef123456", "v0.0.0-20240101120000-abcdef123456", false},
+	}
+
+	for _, p := range pairs {
+		v, err := semver.ParseVersion(p.in)
+		a.NoError(err)
+
+		s, losses, err := VersionToGo(v)
+		a.NoError(err, p.in)
+		a.Equal(p.out, s, p.in)
+		a.Equal(p.lossy, len(losses) > 0, p.in)
+
+		back, err := VersionFromGo(s)
+		a.NoError(err, p.in)
+		if p.lossy {
+			a.Equal(semver.ReleaseBuild, semver.Diff(v, back), p.in)
+		} else {
+			a.True(v.EqualTo(back), p.in)
+		}
+	}
+
+	for _, p := range []struct{ in, out string }{
+		{"v1.2", "1.2.0"},
+		{"v1", "1.0.0"},
+		{"v2.0.0+incompatible", "2.0.0+incompatible"},
+		{"v1.0.0-rc.1", "1.0.0-rc.1"},
+	} {
+		v, err := VersionFromGo(p.in)
+		a.NoError(err, p.in)
+		a.Equal(p.out, v.String(), p.in)
+	}
+
+	for _, s := range []string{"1.2.3", "v1.2.3.4", "v1.0.0-01", ""} {
+		_, err := VersionFromGo(s)
+		a.Error(err, s)
+	}
+
+	_, _, err := VersionToGo(semver.Version{Major: 1, Prerelease: []string{"01"}})
+	a.EqualError(err, "v1.0.0-01: not a valid Go module version")
+}
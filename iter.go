@@ -0,0 +1,124 @@
+package semver
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// LineError is a line that ReadVersions couldn't parse as a version.
+type LineError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %q: %s", e.Line, e.Text, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ReadVersions returns a sequence of the versions in r, one per line. Blank
+// lines are skipped, and lines that don't parse are collected rather than
+// stopping the sequence. Once the sequence has been consumed, the returned
+// function reports them as *LineError values joined together, along with any
+// error reading r. Each pass over the sequence starts the errors afresh.
+func ReadVersions(r io.Reader) (iter.Seq[Version], func() error) {
+	var errs []error
+
+	seq := func(yield func(Version) bool) {
+		errs = nil
+
+		s := bufio.NewScanner(r)
+
+		for n := 1; s.Scan(); n++ {
+			line := strings.TrimSpace(s.Text())
+			if line == "" {
+				continue
+			}
+
+			v, err := ParseVersion(line)
+			if err != nil {
+				errs = append(errs, &LineError{Line: n, Text: line, Err: err})
+				continue
+			}
+
+			if !yield(v) {
+				return
+			}
+		}
+
+		if err := s.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return seq, func() error { return errors.Join(errs...) }
+}
+
+// Values returns a sequence of the versions in l, in order.
+func (l List) Values() iter.Seq[Version] {
+	return func(yield func(Version) bool) {
+		for _, v := range l {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns a sequence of the versions in seq that m accepts.
+func (m *Matcher) Filter(seq iter.Seq[Version]) iter.Seq[Version] {
+	return func(yield func(Version) bool) {
+		for v := range seq {
+			if m.SatisfiedBy(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns a sequence of the versions in seq that satisfy r. It
+// compiles r once up front, so it's cheap however long seq is.
+func (r Range) Filter(seq iter.Seq[Version]) iter.Seq[Version] {
+	return r.Compile().Filter(seq)
+}
+
+// Max returns the highest version in seq, or false if seq is empty.
+func Max(seq iter.Seq[Version]) (Version, bool) {
+	var r Version
+	var ok bool
+
+	for v := range seq {
+		if !ok || v.Compare(r) > 0 {
+			r, ok = v, true
+		}
+	}
+
+	return r, ok
+}
+
+// Min returns the lowest version in seq, or false if seq is empty.
+func Min(seq iter.Seq[Version]) (Version, bool) {
+	var r Version
+	var ok bool
+
+	for v := range seq {
+		if !ok || v.Compare(r) < 0 {
+			r, ok = v, true
+		}
+	}
+
+	return r, ok
+}
+
+// BestMatchSeq is like BestMatch, except that seq can be in any order.
+func (r Range) BestMatchSeq(seq iter.Seq[Version]) (Version, bool) {
+	return Max(r.Filter(seq))
+}
@@ -0,0 +1,270 @@
+package lockfile
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/deoxxa/semver"
+)
+
+// FormatVersion is the lockfileVersion that Write writes and Read accepts.
+const FormatVersion = 1
+
+// Manifest maps each dependency's name to the Range it's declared with.
+type Manifest map[string]semver.Range
+
+// Source is where versions are resolved from, such as a registry.
+type Source interface {
+	Versions(name string) (semver.List, error)
+	Integrity(name string, v semver.Version) (string, error)
+}
+
+// Package is a dependency locked to a single version, along with the Range
+// it was resolved from.
+type Package struct {
+	Version   semver.Version
+	Range     semver.Range
+	Integrity string
+}
+
+// Lockfile records the version each dependency in a Manifest resolved to.
+type Lockfile struct {
+	Packages map[string]Package
+}
+
+// Integrity returns a Subresource Integrity string for data, in the form
+// npm uses.
+func Integrity(data []byte) string {
+	h := sha512.Sum512(data)
+
+	return "sha512-" + base64.StdEncoding.EncodeToString(h[:])
+}
+
+// Resolve locks every dependency in m to the highest version from src that
+// satisfies its range.
+func Resolve(m Manifest, src Source) (*Lockfile, error) {
+	l := &Lockfile{Packages: make(map[string]Package)}
+
+	for _, name := range names(m) {
+		p, err := resolve(name, m[name], src)
+		if err != nil {
+			return nil, err
+		}
+
+		l.Packages[name] = p
+	}
+
+	return l, nil
+}
+
+// Update brings l into line with m. The named dependencies, and any that
+// aren't locked yet, are resolved afresh; every other one keeps its locked
+// version, which must still satisfy m.
+func (l *Lockfile) Update(m Manifest, src Source, update ...string) error {
+	want := make(map[string]bool)
+	for _, name := range update {
+		if _, ok := m[name]; !ok {
+			return fmt.Errorf("%s: not in manifest", name)
+		}
+
+		want[name] = true
+	}
+
+	packages := make(map[string]Package)
+
+	for _, name := range names(m) {
+		r := m[name]
+
+		if p, ok := l.Packages[name]; ok && !want[name] {
+			if !r.SatisfiedBy(p.Version) {
+				return fmt.Errorf("%s: locked version %s does not satisfy %q and was not selected for update", name, p.Version, r)
+			}
+
+			p.Range = r
+			packages[name] = p
+
+			continue
+		}
+
+		p, err := resolve(name, r, src)
+		if err != nil {
+			return err
+		}
+
+		packages[name] = p
+	}
+
+	l.Packages = packages
+
+	return nil
+}
+
+// Verify checks that l locks exactly the dependencies in m, each to a
+// version satisfying its range.
+func (l *Lockfile) Verify(m Manifest) error {
+	var errs []error
+
+	for _, name := range names(m) {
+		p, ok := l.Packages[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: missing from lockfile", name))
+			continue
+		}
+
+		if !m[name].SatisfiedBy(p.Version) {
+			errs = append(errs, fmt.Errorf("%s: locked version %s does not satisfy %q", name, p.Version, m[name]))
+		}
+	}
+
+	for name := range l.Packages {
+		if _, ok := m[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s: not in manifest", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// VerifyIntegrity checks data against the integrity recorded for name.
+func (l *Lockfile) VerifyIntegrity(name string, data []byte) error {
+	p, ok := l.Packages[name]
+	if !ok {
+		return fmt.Errorf("%s: missing from lockfile", name)
+	}
+
+	if got := Integrity(data); got != p.Integrity {
+		return fmt.Errorf("%s: integrity mismatch: expected %s, got %s", name, p.Integrity, got)
+	}
+
+	return nil
+}
+
+type jsonLockfile struct {
+	LockfileVersion int                    `json:"lockfileVersion"`
+	Packages        map[string]jsonPackage `json:"packages"`
+}
+
+type jsonPackage struct {
+	Version   string `json:"version"`
+	Range     string `json:"range"`
+	Integrity string `json:"integrity,omitempty"`
+}
+
+// Write writes l to w as indented JSON.
+func (l *Lockfile) Write(w io.Writer) error {
+	j := jsonLockfile{
+		LockfileVersion: FormatVersion,
+		Packages:        make(map[string]jsonPackage, len(l.Packages)),
+	}
+
+	for name, p := range l.Packages {
+		j.Packages[name] = jsonPackage{
+			Version:   p.Version.String(),
+			Range:     p.Range.String(),
+			Integrity: p.Integrity,
+		}
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+
+	return e.Encode(j)
+}
+
+// Read reads a lockfile written by Write.
+func Read(r io.Reader) (*Lockfile, error) {
+	var j jsonLockfile
+	if err := json.NewDecoder(r).Decode(&j); err != nil {
+		return nil, err
+	}
+
+	if j.LockfileVersion != FormatVersion {
+		return nil, fmt.Errorf("unsupported lockfile version %d", j.LockfileVersion)
+	}
+
+	l := &Lockfile{Packages: make(map[string]Package, len(j.Packages))}
+
+	for name, jp := range j.Packages {
+		v, err := semver.ParseVersion(jp.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		r, err := semver.ParseRange(jp.Range)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		l.Packages[name] = Package{
+			Version:   v,
+			Range:     r,
+			Integrity: jp.Integrity,
+		}
+	}
+
+	return l, nil
+}
+
+// namesPrerelease reports whether r has a comparator on a prerelease of the
+// same major.minor.patch as v. Only then does r ask for prereleases like v,
+// so ^1.0.0 doesn't resolve to 2.0.0-beta.1 but ^1.0.0-beta.1 can resolve
+// to 1.0.0-beta.2.
+func namesPrerelease(r semver.Range, v semver.Version) bool {
+	for _, s := range r {
+		for _, c := range s {
+			if len(c.Version.Prerelease) > 0 && c.Version.Major == v.Major && c.Version.Minor == v.Minor && c.Version.Patch == v.Patch {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func resolve(name string, r semver.Range, src Source) (Package, error) {
+	available, err := src.Versions(name)
+	if err != nil {
+		return Package{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	sorted := make(semver.List, 0, len(available))
+	for _, v := range available {
+		if len(v.Prerelease) == 0 || namesPrerelease(r, v) {
+			sorted = append(sorted, v)
+		}
+	}
+
+	sort.Sort(sorted)
+
+	v, ok := r.BestMatch(sorted)
+	if !ok {
+		return Package{}, fmt.Errorf("%s: no version satisfies %q", name, r)
+	}
+
+	integrity, err := src.Integrity(name, v)
+	if err != nil {
+		return Package{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return Package{
+		Version:   v,
+		Range:     r,
+		Integrity: integrity,
+	}, nil
+}
+
+func names(m Manifest) []string {
+	l := make([]string, 0, len(m))
+	for name := range m {
+		l = append(l, name)
+	}
+
+	sort.Strings(l)
+
+	return l
+}
@@ -0,0 +1,165 @@
+package lockfile
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+type fakeSource map[string][]string
+
+func (s fakeSource) Versions(name string) (semver.List, error) {
+	l, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown package")
+	}
+
+	var r semver.List
+	for _, e := range l {
+		v, err := semver.ParseVersion(e)
+		if err != nil {
+			return nil, err
+		}
+
+		r = append(r, v)
+	}
+
+	return r, nil
+}
+
+func (s fakeSource) Integrity(name string, v semver.Version) (string, error) {
+	return Integrity([]byte(name + "@" + v.String())), nil
+}
+
+func manifest(a *assert.Assertions, m map[string]string) Manifest {
+	r := make(Manifest)
+
+	for name, s := range m {
+		v, err := semver.ParseRange(s)
+		a.NoError(err, s)
+
+		r[name] = v
+	}
+
+	return r
+}
+
+func TestResolveAndVerify(t *testing.T) {
+	a := assert.New(t)
+
+	src := fakeSource{
+		"a": {"1.0.0", "1.2.0", "1.1.0", "2.0.0"},
+		"b": {"0.1.0", "0.1.5", "0.2.0"},
+	}
+
+	m := manifest(a, map[string]string{"a": "^1.0.0", "b": "~0.1.0"})
+
+	l, err := Resolve(m, src)
+	a.NoError(err)
+
+	a.Equal("1.2.0", l.Packages["a"].Version.String())
+	a.Equal("0.1.5", l.Packages["b"].Version.String())
+	a.Equal(Integrity([]byte("a@1.2.0")), l.Packages["a"].Integrity)
+	a.NoError(l.Verify(m))
+	a.NoError(l.VerifyIntegrity("a", []byte("a@1.2.0")))
+	a.Error(l.VerifyIntegrity("a", []byte("a@1.1.0")))
+
+	a.Error(l.Verify(manifest(a, map[string]string{"a": "^2.0.0", "b": "~0.1.0"})))
+	a.Error(l.Verify(manifest(a, map[string]string{"a": "^1.0.0"})))
+	a.Error(l.Verify(manifest(a, map[string]string{"a": "^1.0.0", "b": "~0.1.0", "c": "1"})))
+
+	_, err = Resolve(manifest(a, map[string]string{"a": "^3.0.0"}), src)
+	a.Error(err)
+}
+
+func TestResolvePrerelease(t *testing.T) {
+	a := assert.New(t)
+
+	src := fakeSource{
+		"a": {"1.0.0", "1.2.0", "1.3.0-rc.1", "2.0.0-beta.1"},
+		"b": {"1.0.0-beta.1", "1.0.0-beta.2", "1.1.0-alpha"},
+	}
+
+	pairs := []struct {
+		name, rng, out string
+	}{
+		{"a", "^1.0.0", "1.2.0"},
+		{"a", ">=1.0.0", "1.2.0"},
+		{"a", ">=1.3.0-rc.0", "1.3.0-rc.1"},
+		{"a", "^2.0.0-alpha", "2.0.0-beta.1"},
+		{"b", "^1.0.0-beta.1", "1.0.0-beta.2"},
+	}
+
+	for _, p := range pairs {
+		l, err := Resolve(manifest(a, map[string]string{p.name: p.rng}), src)
+		if a.NoError(err, p.rng) {
+			a.Equal(p.out, l.Packages[p.name].Version.String(), p.rng)
+		}
+	}
+
+	_, err := Resolve(manifest(a, map[string]string{"b": "^1.0.0"}), src)
+	a.Error(err)
+}
+
+func TestReadWrite(t *testing.T) {
+	a := assert.New(t)
+
+	src := fakeSource{"a": {"1.0.0", "1.2.0-beta.1+abc", "1.2.0-beta.1"}}
+	m := manifest(a, map[string]string{"a": ">=1.2.0-beta.1 <2"})
+
+	l, err := Resolve(m, src)
+	a.NoError(err)
+
+	var buf bytes.Buffer
+	a.NoError(l.Write(&buf))
+
+	l2, err := Read(&buf)
+	a.NoError(err)
+	a.Equal(l.Packages["a"].Version.String(), l2.Packages["a"].Version.String())
+	a.Equal(l.Packages["a"].Range.String(), l2.Packages["a"].Range.String())
+	a.Equal(l.Packages["a"].Integrity, l2.Packages["a"].Integrity)
+	a.NoError(l2.Verify(m))
+
+	_, err = Read(bytes.NewBufferString(`{"lockfileVersion": 99, "packages": {}}`))
+	a.Error(err)
+}
+
+func TestUpdate(t *testing.T) {
+	a := assert.New(t)
+
+	src := fakeSource{
+		"a": {"1.0.0"},
+		"b": {"1.0.0"},
+	}
+
+	m := manifest(a, map[string]string{"a": "^1", "b": "^1"})
+
+	l, err := Resolve(m, src)
+	a.NoError(err)
+
+	src["a"] = append(src["a"], "1.1.0")
+	src["b"] = append(src["b"], "1.1.0")
+	src["c"] = []string{"3.0.0"}
+
+	m["c"] = manifest(a, map[string]string{"c": "3"})["c"]
+
+	a.NoError(l.Update(m, src, "a"))
+	a.Equal("1.1.0", l.Packages["a"].Version.String())
+	a.Equal("1.0.0", l.Packages["b"].Version.String())
+	a.Equal("3.0.0", l.Packages["c"].Version.String())
+
+	delete(m, "c")
+	a.NoError(l.Update(m, src))
+	a.NotContains(l.Packages, "c")
+
+	m["b"] = manifest(a, map[string]string{"b": "^1.1"})["b"]
+	a.Error(l.Update(m, src, "a"))
+	a.NoError(l.Update(m, src, "b"))
+	a.Equal("1.1.0", l.Packages["b"].Version.String())
+
+	a.Error(l.Update(m, src, "zzz"))
+}
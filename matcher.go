@@ -0,0 +1,371 @@
+package semver
+
+import (
+	"sort"
+)
+
+type bound struct {
+	version   Version
+	inclusive bool
+	unbounded bool
+}
+
+func (b bound) below(v Version) bool {
+	if b.unbounded {
+		return false
+	}
+
+	c := comparePrecedence(b.version, v)
+
+	return c < 0 || (c == 0 && !b.inclusive)
+}
+
+func (b bound) above(v Version) bool {
+	if b.unbounded {
+		return false
+	}
+
+	c := comparePrecedence(b.version, v)
+
+	return c > 0 || (c == 0 && !b.inclusive)
+}
+
+type interval struct {
+	lower, upper bound
+}
+
+func (i interval) empty() bool {
+	if i.lower.unbounded || i.upper.unbounded {
+		return false
+	}
+
+	c := comparePrecedence(i.lower.version, i.upper.version)
+
+	return c > 0 || (c == 0 && !(i.lower.inclusive && i.upper.inclusive))
+}
+
+func (i *interval) raiseLower(b bound) {
+	if b.unbounded {
+		return
+	}
+
+	if i.lower.unbounded {
+		i.lower = b
+		return
+	}
+
+	if c := comparePrecedence(b.version, i.lower.version); c > 0 || (c == 0 && !b.inclusive) {
+		i.lower = b
+	}
+}
+
+func (i *interval) lowerUpper(b bound) {
+	if b.unbounded {
+		return
+	}
+
+	if i.upper.unbounded {
+		i.upper = b
+		return
+	}
+
+	if c := comparePrecedence(b.version, i.upper.version); c < 0 || (c == 0 && !b.inclusive) {
+		i.upper = b
+	}
+}
+
+// setIntervals returns the disjoint intervals a Set describes, in order. It's
+// a single interval unless the Set excludes individual versions with !=.
+func setIntervals(s Set) []interval {
+	i := interval{
+		lower: bound{unbounded: true},
+		upper: bound{unbounded: true},
+	}
+
+	var excluded []Version
+
+	for _, c := range s {
+		v := cloneVersion(c.Version)
+
+		switch c.Operator {
+		case OperatorGT:
+			i.raiseLower(bound{version: v})
+		case OperatorGTE:
+			i.raiseLower(bound{version: v, inclusive: true})
+		case OperatorLT:
+			i.lowerUpper(bound{version: v})
+		case OperatorLTE:
+			i.lowerUpper(bound{version: v, inclusive: true})
+		case OperatorNone, OperatorEQ:
+			i.raiseLower(bound{version: v, inclusive: true})
+			i.lowerUpper(bound{version: v, inclusive: true})
+		case OperatorNE:
+			excluded = append(excluded, v)
+		default:
+			return nil
+		}
+	}
+
+	if i.empty() {
+		return nil
+	}
+
+	l := []interval{i}
+
+	for _, v := range excluded {
+		var next []interval
+
+		for _, i := range l {
+			if i.lower.above(v) || i.upper.below(v) {
+				next = append(next, i)
+				continue
+			}
+
+			if a := (interval{lower: i.lower, upper: bound{version: v}}); !a.empty() {
+				next = append(next, a)
+			}
+
+			if b := (interval{lower: bound{version: v}, upper: i.upper}); !b.empty() {
+				next = append(next, b)
+			}
+		}
+
+		l = next
+	}
+
+	return l
+}
+
+func cloneVersion(v Version) Version {
+	if v.Prerelease != nil {
+		v.Prerelease = append([]string(nil), v.Prerelease...)
+	}
+
+	if v.Build != nil {
+		v.Build = append([]string(nil), v.Build...)
+	}
+
+	return v
+}
+
+// Matcher is a precompiled form of a Range. It holds the Range as a sorted
+// list of disjoint intervals, so checking a version is a binary search that
+// doesn't allocate. A Matcher is immutable and safe for concurrent use.
+type Matcher struct {
+	intervals []interval
+}
+
+// Compile returns a Matcher that accepts the same versions as r.
+func (r Range) Compile() *Matcher {
+	var l []interval
+
+	for _, s := range r {
+		l = append(l, setIntervals(s)...)
+	}
+
+	return &Matcher{intervals: normalize(l)}
+}
+
+// minVersion sorts below every other version, so ">=0.0.0-0" is the same as
+// having no lower bound and "<0.0.0-0" can't be satisfied.
+var minVersion = Version{Prerelease: []string{"0"}}
+
+// normalize sorts intervals and merges any that overlap or touch, leaving
+// the disjoint list a Matcher needs.
+func normalize(in []interval) []interval {
+	var l []interval
+
+	for _, i := range in {
+		if !i.upper.unbounded && i.upper.below(minVersion) {
+			continue
+		}
+
+		if !i.lower.unbounded && i.lower.inclusive && comparePrecedence(i.lower.version, minVersion) <= 0 {
+			i.lower = bound{unbounded: true}
+		}
+
+		l = append(l, i)
+	}
+
+	sort.Slice(l, func(a, b int) bool {
+		x, y := l[a].lower, l[b].lower
+
+		if x.unbounded || y.unbounded {
+			return x.unbounded && !y.unbounded
+		}
+
+		if c := comparePrecedence(x.version, y.version); c != 0 {
+			return c < 0
+		}
+
+		return x.inclusive && !y.inclusive
+	})
+
+	var r []interval
+
+	for _, i := range l {
+		if len(r) == 0 {
+			r = append(r, i)
+			continue
+		}
+
+		last := &r[len(r)-1]
+
+		if last.upper.below(i.lower.version) && !(i.lower.inclusive && comparePrecedence(last.upper.version, i.lower.version) == 0) && !i.lower.unbounded {
+			r = append(r, i)
+			continue
+		}
+
+		if !last.upper.unbounded && (i.upper.unbounded || last.upper.below(i.upper.version) || (i.upper.inclusive && comparePrecedence(last.upper.version, i.upper.version) == 0)) {
+			last.upper = i.upper
+		}
+	}
+
+	return r
+}
+
+func (b bound) equal(o bound) bool {
+	if b.unbounded || o.unbounded {
+		return b.unbounded == o.unbounded
+	}
+
+	return b.inclusive == o.inclusive && comparePrecedence(b.version, o.version) == 0
+}
+
+// upperBefore reports whether upper bound a ends before upper bound b does.
+func upperBefore(a, b bound) bool {
+	if a.unbounded || b.unbounded {
+		return !a.unbounded && b.unbounded
+	}
+
+	c := comparePrecedence(a.version, b.version)
+
+	return c < 0 || (c == 0 && !a.inclusive && b.inclusive)
+}
+
+func (m *Matcher) union(o *Matcher) *Matcher {
+	l := make([]interval, 0, len(m.intervals)+len(o.intervals))
+	l = append(l, m.intervals...)
+	l = append(l, o.intervals...)
+
+	return &Matcher{intervals: normalize(l)}
+}
+
+func (m *Matcher) intersect(o *Matcher) *Matcher {
+	var l []interval
+
+	for i, j := 0, 0; i < len(m.intervals) && j < len(o.intervals); {
+		x := m.intervals[i]
+		x.raiseLower(o.intervals[j].lower)
+		x.lowerUpper(o.intervals[j].upper)
+
+		if !x.empty() {
+			l = append(l, x)
+		}
+
+		if upperBefore(m.intervals[i].upper, o.intervals[j].upper) {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return &Matcher{intervals: normalize(l)}
+}
+
+func (m *Matcher) equal(o *Matcher) bool {
+	if len(m.intervals) != len(o.intervals) {
+		return false
+	}
+
+	for i := range m.intervals {
+		if !m.intervals[i].lower.equal(o.intervals[i].lower) || !m.intervals[i].upper.equal(o.intervals[i].upper) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// complement's result is already normalized, as the gaps between disjoint
+// intervals are themselves disjoint and in order.
+func (m *Matcher) complement() *Matcher {
+	lower := bound{unbounded: true}
+
+	var l []interval
+
+	for _, i := range m.intervals {
+		if !i.lower.unbounded {
+			l = append(l, interval{
+				lower: lower,
+				upper: bound{version: i.lower.version, inclusive: !i.lower.inclusive},
+			})
+		}
+
+		if i.upper.unbounded {
+			return &Matcher{intervals: l}
+		}
+
+		lower = bound{version: i.upper.version, inclusive: !i.upper.inclusive}
+	}
+
+	return &Matcher{intervals: append(l, interval{lower: lower, upper: bound{unbounded: true}})}
+}
+
+// Range converts the Matcher back into an equivalent Range, with one Set per
+// interval. A Matcher that nothing satisfies becomes "<0.0.0-0", since no
+// version is lower than that.
+func (m *Matcher) Range() Range {
+	if len(m.intervals) == 0 {
+		return Range{Set{{Operator: OperatorLT, Version: cloneVersion(minVersion)}}}
+	}
+
+	r := make(Range, len(m.intervals))
+
+	for n, i := range m.intervals {
+		s := Set{}
+
+		switch {
+		case !i.lower.unbounded && !i.upper.unbounded && i.lower.inclusive && i.upper.inclusive && comparePrecedence(i.lower.version, i.upper.version) == 0:
+			s = append(s, Comparator{Operator: OperatorEQ, Version: cloneVersion(i.lower.version)})
+		default:
+			if !i.lower.unbounded {
+				c := Comparator{Operator: OperatorGT, Version: cloneVersion(i.lower.version)}
+				if i.lower.inclusive {
+					c.Operator = OperatorGTE
+				}
+
+				s = append(s, c)
+			}
+
+			if !i.upper.unbounded {
+				c := Comparator{Operator: OperatorLT, Version: cloneVersion(i.upper.version)}
+				if i.upper.inclusive {
+					c.Operator = OperatorLTE
+				}
+
+				s = append(s, c)
+			}
+		}
+
+		r[n] = s
+	}
+
+	return r
+}
+
+func (m *Matcher) SatisfiedBy(v Version) bool {
+	lo, hi := 0, len(m.intervals)
+
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+
+		if m.intervals[mid].upper.below(v) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo < len(m.intervals) && !m.intervals[lo].lower.above(v)
+}
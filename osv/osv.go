@@ -0,0 +1,305 @@
+// Package osv matches versions against vulnerability advisories in the OSV
+// format (https://ossf.github.io/osv-schema/).
+//
+// Only SEMVER ranges and explicit lists of affected versions are used. GIT
+// ranges, and ECOSYSTEM ranges whose ordering isn't semver, are ignored.
+package osv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/deoxxa/semver"
+)
+
+type Advisory struct {
+	ID       string     `json:"id"`
+	Summary  string     `json:"summary,omitempty"`
+	Aliases  []string   `json:"aliases,omitempty"`
+	Affected []Affected `json:"affected"`
+}
+
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+type Affected struct {
+	Package  Package  `json:"package"`
+	Ranges   []Range  `json:"ranges,omitempty"`
+	Versions []string `json:"versions,omitempty"`
+}
+
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Event is one of the events of a Range; exactly one field is set.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+type event struct {
+	kind    string
+	version semver.Version
+}
+
+// eventOrder breaks ties between events at the same version, so that a fix
+// and a reintroduction at one version leave it affected, and a span that's
+// introduced and last affected at one version covers just that version.
+var eventOrder = map[string]int{"fixed": 0, "introduced": 1, "last_affected": 2}
+
+// EventsRange converts the events of a SEMVER range into the Range of
+// versions they describe. Events can be in any order. Each introduced event
+// starts a span of affected versions that runs up to the next fixed event,
+// or up to and including the next last_affected event, or on forever if
+// there isn't one. Where events share a version, a fixed event comes before
+// an introduced one, and an introduced event before a last_affected one. An
+// introduced version of "0" means the very first version. Limit events only
+// apply to GIT ranges, and are ignored.
+func EventsRange(events []Event) (semver.Range, error) {
+	var l []event
+
+	for _, e := range events {
+		var kind, s string
+
+		switch {
+		case e.Introduced != "":
+			kind, s = "introduced", e.Introduced
+		case e.Fixed != "":
+			kind, s = "fixed", e.Fixed
+		case e.LastAffected != "":
+			kind, s = "last_affected", e.LastAffected
+		default:
+			continue
+		}
+
+		if kind == "introduced" && s == "0" {
+			l = append(l, event{kind: kind, version: semver.Version{Prerelease: []string{"0"}}})
+			continue
+		}
+
+		v, err := semver.ParseVersion(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", kind, s, err)
+		}
+
+		l = append(l, event{kind: kind, version: v})
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		if !l[i].version.EqualTo(l[j].version) {
+			return l[i].version.LessThan(l[j].version)
+		}
+
+		return eventOrder[l[i].kind] < eventOrder[l[j].kind]
+	})
+
+	r := semver.Range{}
+
+	var start *semver.Version
+
+	for i, e := range l {
+		switch e.kind {
+		case "introduced":
+			if start == nil {
+				start = &l[i].version
+			}
+		case "fixed", "last_affected":
+			if start == nil {
+				continue
+			}
+
+			op := semver.Operator(semver.OperatorLT)
+			if e.kind == "last_affected" {
+				op = semver.OperatorLTE
+			}
+
+			r = append(r, semver.Set{{Operator: semver.OperatorGTE, Version: *start}, {Operator: op, Version: e.version}})
+			start = nil
+		}
+	}
+
+	if start != nil {
+		r = append(r, semver.Set{{Operator: semver.OperatorGTE, Version: *start}})
+	}
+
+	return r.Compile().Range(), nil
+}
+
+// Range returns the versions a describes as affected, from both its SEMVER
+// ranges and its list of versions. Listed versions that don't parse are
+// skipped, since OSV lists versions in whatever form the ecosystem uses.
+func (a Affected) Range() (semver.Range, error) {
+	r := semver.Range{}
+
+	for _, rng := range a.Ranges {
+		if rng.Type != "SEMVER" {
+			continue
+		}
+
+		x, err := EventsRange(rng.Events)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", a.Package.Name, err)
+		}
+
+		r = append(r, x...)
+	}
+
+	for _, s := range a.Versions {
+		v, err := semver.ParseVersion(s)
+		if err != nil {
+			continue
+		}
+
+		r = append(r, semver.Set{{Operator: semver.OperatorEQ, Version: v}})
+	}
+
+	return r.Compile().Range(), nil
+}
+
+func Parse(r io.Reader) (*Advisory, error) {
+	var a Advisory
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+type entry struct {
+	advisory *Advisory
+	affected *semver.Matcher
+	rng      semver.Range
+}
+
+// Database indexes advisories by the packages they affect.
+type Database struct {
+	packages map[Package][]entry
+}
+
+func NewDatabase(advisories ...*Advisory) (*Database, error) {
+	db := Database{packages: map[Package][]entry{}}
+
+	for _, a := range advisories {
+		for _, af := range a.Affected {
+			r, err := af.Range()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", a.ID, err)
+			}
+
+			db.packages[af.Package] = append(db.packages[af.Package], entry{
+				advisory: a,
+				affected: r.Compile(),
+				rng:      r,
+			})
+		}
+	}
+
+	return &db, nil
+}
+
+// LoadDir reads every .json file in dir as an advisory.
+func LoadDir(dir string) (*Database, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	var l []*Advisory
+
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+
+		a, err := Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		l = append(l, a)
+	}
+
+	return NewDatabase(l...)
+}
+
+// Match is an advisory that affects a version. Fixed is the nearest
+// version above it that the advisory doesn't affect, if there is one.
+type Match struct {
+	Advisory *Advisory
+	Range    semver.Range
+	Fixed    *semver.Version
+}
+
+// Result is the answer to a Query. Fixed is the nearest version above the
+// one queried that none of the advisories affect, if there is one.
+type Result struct {
+	Matches []Match
+	Fixed   *semver.Version
+}
+
+func (r Result) Affected() bool {
+	return len(r.Matches) > 0
+}
+
+// nearestFixed returns the lowest version above v that affected doesn't
+// include. Where an advisory only says which version was last affected, this
+// is the lowest version that could follow it, such as 1.5.4 after 1.5.3.
+func nearestFixed(v semver.Version, affected semver.Range) *semver.Version {
+	above := semver.Range{{{Operator: semver.OperatorGT, Version: v}}}
+
+	if f, ok := above.Difference(affected).MinVersion(); ok {
+		return &f
+	}
+
+	return nil
+}
+
+// Query returns the advisories that affect version v of the named package.
+// An empty ecosystem matches a package of that name in any ecosystem.
+func (db *Database) Query(ecosystem, name string, v semver.Version) Result {
+	var res Result
+
+	affected := semver.Range{}
+
+	for p, entries := range db.packages {
+		if p.Name != name || (ecosystem != "" && p.Ecosystem != ecosystem) {
+			continue
+		}
+
+		for _, e := range entries {
+			if !e.affected.SatisfiedBy(v) {
+				continue
+			}
+
+			res.Matches = append(res.Matches, Match{
+				Advisory: e.advisory,
+				Range:    e.rng,
+				Fixed:    nearestFixed(v, e.rng),
+			})
+
+			affected = append(affected, e.rng...)
+		}
+	}
+
+	sort.Slice(res.Matches, func(i, j int) bool { return res.Matches[i].Advisory.ID < res.Matches[j].Advisory.ID })
+
+	if res.Affected() {
+		res.Fixed = nearestFixed(v, affected)
+	}
+
+	return res
+}
@@ -0,0 +1,149 @@
+package osv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+func TestEventsRange(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		events  []Event
+		out     string
+		yes, no []string
+	}{
+		{
+			[]Event{{Introduced: "0"}, {Fixed: "1.2.3"}},
+			"<1.2.3",
+			[]string{"0.0.0", "0.0.0-alpha", "1.2.2"},
+			[]string{"1.2.3", "2.0.0"},
+		},
+		{
+			[]Event{{Introduced: "1.0.0"}, {LastAffected: "1.4.0"}},
+			">=1.0.0 <=1.4.0",
+			[]string{"1.0.0", "1.4.0"},
+			[]string{"0.9.0", "1.4.1"},
+		},
+		{
+			[]Event{{Fixed: "1.1.0"}, {Introduced: "1.0.0"}, {Introduced: "2.0.0"}},
+			">=1.0.0 <1.1.0 || >=2.0.0",
+			[]string{"1.0.5", "2.0.0", "9.0.0"},
+			[]string{"1.1.0", "1.9.0"},
+		},
+		{
+			[]Event{{Introduced: "1.0.0"}, {Fixed: "1.1.0"}, {Limit: "*"}},
+			">=1.0.0 <1.1.0",
+			[]string{"1.0.0"},
+			[]string{"1.1.0"},
+		},
+		{
+			[]Event{{Fixed: "1.0.0"}, {Introduced: "1.0.0"}},
+			">=1.0.0",
+			[]string{"1.0.0", "2.0.0"},
+			[]string{"0.9.0"},
+		},
+		{
+			[]Event{{Introduced: "1.0.0"}, {Fixed: "1.0.0"}},
+			">=1.0.0",
+			[]string{"1.0.0", "2.0.0"},
+			[]string{"0.9.0"},
+		},
+		{
+			[]Event{{LastAffected: "1.0.0"}, {Introduced: "1.0.0"}},
+			"=1.0.0",
+			[]string{"1.0.0"},
+			[]string{"0.9.0", "1.0.1"},
+		},
+		{
+			[]Event{{Fixed: "1.0.0"}},
+			"<0.0.0-0",
+			nil,
+			[]string{"0.0.0", "1.0.0"},
+		},
+	}
+
+	for _, p := range pairs {
+		r, err := EventsRange(p.events)
+		if !a.NoError(err, p.out) {
+			continue
+		}
+
+		a.Equal(p.out, r.String())
+
+		for _, s := range p.yes {
+			v, err := semver.ParseVersion(s)
+			a.NoError(err)
+			a.True(r.SatisfiedBy(v), "%s: %s", p.out, s)
+		}
+
+		for _, s := range p.no {
+			v, err := semver.ParseVersion(s)
+			a.NoError(err)
+			a.False(r.SatisfiedBy(v), "%s: %s", p.out, s)
+		}
+	}
+
+	_, err := EventsRange([]Event{{Introduced: "1.0"}})
+	a.Error(err)
+}
+
+func TestQuery(t *testing.T) {
+	a := assert.New(t)
+
+	db, err := LoadDir("testdata")
+	if !a.NoError(err) {
+		return
+	}
+
+	pairs := []struct {
+		ecosystem, name, version string
+		ids                      []string
+		fixed                    string
+	}{
+		{"npm", "lodash", "4.17.15", []string{"GHSA-35jh-r3h4-6jhm", "GHSA-p6mc-m468-83gw"}, "4.17.21"},
+		{"npm", "lodash", "4.17.20", []string{"GHSA-35jh-r3h4-6jhm"}, "4.17.21"},
+		{"npm", "lodash", "4.17.21", nil, ""},
+		{"", "lodash", "4.17.19", []string{"GHSA-35jh-r3h4-6jhm"}, "4.17.21"},
+		{"PyPI", "lodash", "4.17.15", nil, ""},
+		{"Go", "example.com/proxy", "1.1.0", nil, ""},
+		{"Go", "example.com/proxy", "1.3.0", []string{"GO-2023-0001"}, "1.4.2"},
+		{"Go", "example.com/proxy", "1.4.2", nil, ""},
+		{"Go", "example.com/proxy", "1.5.1", []string{"GO-2023-0001"}, "1.5.4"},
+		{"Go", "example.com/proxy", "2.0.5", []string{"GO-2023-0001"}, "2.1.0"},
+		{"crates.io", "example-crate", "0.1.4", []string{"RUSTSEC-2023-0002"}, "0.1.5-0"},
+		{"crates.io", "example-crate", "0.1.6", nil, ""},
+		{"crates.io", "example-crate", "0.4.0", []string{"RUSTSEC-2023-0002"}, ""},
+	}
+
+	for _, p := range pairs {
+		v, err := semver.ParseVersion(p.version)
+		a.NoError(err)
+
+		res := db.Query(p.ecosystem, p.name, v)
+
+		var ids []string
+		for _, m := range res.Matches {
+			ids = append(ids, m.Advisory.ID)
+		}
+
+		a.Equal(p.ids, ids, "%s@%s", p.name, p.version)
+		a.Equal(len(p.ids) > 0, res.Affected(), "%s@%s", p.name, p.version)
+
+		if p.fixed == "" {
+			a.Nil(res.Fixed, "%s@%s", p.name, p.version)
+		} else if a.NotNil(res.Fixed, "%s@%s", p.name, p.version) {
+			a.Equal(p.fixed, res.Fixed.String(), "%s@%s", p.name, p.version)
+		}
+	}
+
+	res := db.Query("npm", "lodash", semver.Version{Major: 4, Minor: 17, Patch: 15})
+	if a.Len(res.Matches, 2) {
+		a.Equal("4.17.21", res.Matches[0].Fixed.String())
+		a.Equal("4.17.19", res.Matches[1].Fixed.String())
+		a.Equal([]string{"CVE-2020-8203"}, res.Matches[1].Advisory.Aliases)
+	}
+}
@@ -0,0 +1,277 @@
+// Package packagejson reads the dependencies declared in an npm package.json
+// file, and points out ranges that are probably mistakes.
+package packagejson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/deoxxa/semver"
+)
+
+type DependencyType string
+
+const (
+	Dependencies         DependencyType = "dependencies"
+	DevDependencies      DependencyType = "devDependencies"
+	PeerDependencies     DependencyType = "peerDependencies"
+	OptionalDependencies DependencyType = "optionalDependencies"
+	Engines              DependencyType = "engines"
+)
+
+// DependencyTypes lists every section of package.json that's read, in the
+// order they're reported.
+var DependencyTypes = []DependencyType{Dependencies, DevDependencies, PeerDependencies, OptionalDependencies, Engines}
+
+// Kind is the sort of thing a dependency specifier refers to.
+type Kind string
+
+const (
+	KindRange     Kind = "range"
+	KindTag       Kind = "tag"
+	KindAlias     Kind = "alias"
+	KindWorkspace Kind = "workspace"
+	KindGit       Kind = "git"
+	KindFile      Kind = "file"
+	KindURL       Kind = "url"
+	KindInvalid   Kind = "invalid"
+)
+
+// Spec is a classified dependency specifier. Range is set for ranges, for
+// aliases and workspace references that have one, and for git URLs that
+// pick a tag with "#semver:". An alias is "npm:<Package>@<range or tag>",
+// and Tag is the dist-tag for tags and aliases of tags, which for an alias
+// without either is "latest". Err explains why a
+// specifier is KindInvalid.
+type Spec struct {
+	Raw     string
+	Kind    Kind
+	Range   semver.Range
+	Package string
+	Tag     string
+	Err     error
+}
+
+var (
+	tagRegexp       = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*$`)
+	shorthandRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+(#.*)?$`)
+	gitPrefixes     = []string{"git+", "git://", "git@", "github:", "gitlab:", "bitbucket:", "gist:"}
+	filePrefixes    = []string{"file:", "link:", "./", "../", "/", "~/"}
+)
+
+// ParseSpec classifies a specifier the way npm would read it. It never
+// fails; specifiers it can't make sense of come back as KindInvalid.
+func ParseSpec(s string) Spec {
+	spec := Spec{Raw: s}
+	t := strings.TrimSpace(s)
+
+	switch {
+	case strings.HasPrefix(t, "npm:"):
+		spec.Kind = KindAlias
+
+		name, rest := t[len("npm:"):], "latest"
+		if i := strings.LastIndexByte(name, '@'); i > 0 {
+			name, rest = name[:i], name[i+1:]
+		}
+
+		if name == "" {
+			spec.Kind, spec.Err = KindInvalid, fmt.Errorf("%s: alias has no package name", s)
+			return spec
+		}
+
+		spec.Package = name
+
+		switch target := ParseSpec(rest); target.Kind {
+		case KindRange:
+			spec.Range = target.Range
+		case KindTag:
+			spec.Tag = target.Tag
+		default:
+			spec.Kind, spec.Err = KindInvalid, fmt.Errorf("%s: alias must be to a range or tag", s)
+		}
+	case strings.HasPrefix(t, "workspace:"):
+		spec.Kind = KindWorkspace
+
+		switch rest := t[len("workspace:"):]; rest {
+		case "^", "~":
+		default:
+			r, err := semver.ParseRange(rest)
+			if err != nil {
+				spec.Kind, spec.Err = KindInvalid, fmt.Errorf("%s: %w", s, err)
+				return spec
+			}
+
+			spec.Range = r
+		}
+	case hasPrefix(t, gitPrefixes...) || (hasPrefix(t, "http://", "https://") && strings.HasSuffix(strings.SplitN(t, "#", 2)[0], ".git")) || shorthandRegexp.MatchString(t):
+		spec.Kind = KindGit
+
+		if _, ref, ok := strings.Cut(t, "#"); ok && strings.HasPrefix(ref, "semver:") {
+			r, err := semver.ParseRange(ref[len("semver:"):])
+			if err != nil {
+				spec.Kind, spec.Err = KindInvalid, fmt.Errorf("%s: %w", s, err)
+				return spec
+			}
+
+			spec.Range = r
+		}
+	case hasPrefix(t, filePrefixes...):
+		spec.Kind = KindFile
+	case hasPrefix(t, "http://", "https://"):
+		spec.Kind = KindURL
+	default:
+		r, err := semver.ParseRange(t)
+
+		switch {
+		case err == nil:
+			spec.Kind, spec.Range = KindRange, r
+		case tagRegexp.MatchString(t):
+			spec.Kind, spec.Tag = KindTag, t
+		default:
+			spec.Kind, spec.Err = KindInvalid, fmt.Errorf("%s: %w", s, err)
+		}
+	}
+
+	return spec
+}
+
+func hasPrefix(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Dependency struct {
+	Name string
+	Type DependencyType
+	Spec
+}
+
+// Manifest is the part of a package.json file this package cares about.
+// Dependencies are sorted by type, in the order of DependencyTypes, and then
+// by name.
+type Manifest struct {
+	Name         string
+	Version      string
+	Dependencies []Dependency
+}
+
+func Parse(r io.Reader) (*Manifest, error) {
+	var raw struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+		Engines              map[string]string `json:"engines"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	m := Manifest{Name: raw.Name, Version: raw.Version}
+
+	for _, t := range DependencyTypes {
+		var deps map[string]string
+
+		switch t {
+		case Dependencies:
+			deps = raw.Dependencies
+		case DevDependencies:
+			deps = raw.DevDependencies
+		case PeerDependencies:
+			deps = raw.PeerDependencies
+		case OptionalDependencies:
+			deps = raw.OptionalDependencies
+		case Engines:
+			deps = raw.Engines
+		}
+
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			m.Dependencies = append(m.Dependencies, Dependency{Name: name, Type: t, Spec: ParseSpec(deps[name])})
+		}
+	}
+
+	return &m, nil
+}
+
+func ReadFile(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+type Problem string
+
+const (
+	// ProblemInvalid is a specifier that isn't any kind npm understands.
+	ProblemInvalid Problem = "invalid"
+	// ProblemUnsatisfiable is a range that no version satisfies, like
+	// ">2 <1".
+	ProblemUnsatisfiable Problem = "unsatisfiable"
+	// ProblemBroad is a range that accepts every release from 0.0.0 up,
+	// like "*", "" or ">=0".
+	ProblemBroad Problem = "broad"
+)
+
+type Finding struct {
+	Dependency Dependency
+	Problem    Problem
+	Message    string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s %q: %s", f.Dependency.Type, f.Dependency.Name, f.Dependency.Raw, f.Message)
+}
+
+var releases = semver.Range{{{Operator: semver.OperatorGTE}}}
+
+// Audit reports each dependency with an invalid specifier, or a range that's
+// unsatisfiable or overly broad. Aliases and git URLs are checked by their
+// ranges, if they have one. Workspace references are left alone, since
+// "workspace:*" is the usual way to write them.
+func (m *Manifest) Audit() []Finding {
+	var l []Finding
+
+	for _, d := range m.Dependencies {
+		switch {
+		case d.Kind == KindInvalid:
+			l = append(l, Finding{Dependency: d, Problem: ProblemInvalid, Message: d.Err.Error()})
+		case d.Kind == KindWorkspace || d.Range == nil:
+		case d.Range.IsEmpty():
+			l = append(l, Finding{Dependency: d, Problem: ProblemUnsatisfiable, Message: "no version satisfies " + d.Range.String()})
+		case releases.Difference(d.Range).IsEmpty():
+			l = append(l, Finding{Dependency: d, Problem: ProblemBroad, Message: "every version satisfies the range"})
+		}
+	}
+
+	return l
+}
@@ -0,0 +1,142 @@
+package packagejson
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpec(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		in, kind, r, pkg, tag string
+	}{
+		{"^1.2.3", "range", ">=1.2.3 <2.0.0", "", ""},
+		{"1.x || >=3", "range", ">=1.0.0 <2.0.0 || >=3.0.0", "", ""},
+		{"", "range", "", "", ""},
+		{"*", "range", ">=0.0.0", "", ""},
+		{"latest", "tag", "", "", "latest"},
+		{"next-11", "tag", "", "", "next-11"},
+		{"npm:lodash@^4.17.0", "alias", ">=4.17.0 <5.0.0", "lodash", ""},
+		{"npm:@scope/pkg@~1.2", "alias", ">=1.2.0 <1.3.0", "@scope/pkg", ""},
+		{"npm:@scope/pkg@beta", "alias", "", "@scope/pkg", "beta"},
+		{"npm:react", "alias", "", "react", "latest"},
+		{"workspace:*", "workspace", ">=0.0.0", "", ""},
+		{"workspace:^", "workspace", "", "", ""},
+		{"workspace:^1.0.0", "workspace", ">=1.0.0 <2.0.0", "", ""},
+		{"git+https://github.com/user/repo.git#v1.0.0", "git", "", "", ""},
+		{"git+ssh://git@github.com:user/repo.git#semver:^2.0", "git", ">=2.0.0 <3.0.0", "", ""},
+		{"git@github.com:user/repo.git", "git", "", "", ""},
+		{"github:user/repo", "git", "", "", ""},
+		{"user/repo#main", "git", "", "", ""},
+		{"https://example.com/repo.git", "git", "", "", ""},
+		{"https://example.com/pkg-1.0.0.tgz", "url", "", "", ""},
+		{"file:../local", "file", "", "", ""},
+		{"./vendor/thing", "file", "", "", ""},
+		{"link:../other", "file", "", "", ""},
+		{"1.2.3 yes please", "invalid", "", "", ""},
+		{"npm:@scope/pkg@file:../x", "invalid", "", "@scope/pkg", ""},
+		{"workspace:nope", "invalid", "", "", ""},
+	}
+
+	for _, p := range pairs {
+		s := ParseSpec(p.in)
+
+		a.Equal(Kind(p.kind), s.Kind, p.in)
+		a.Equal(p.in, s.Raw, p.in)
+		a.Equal(p.pkg, s.Package, p.in)
+		a.Equal(p.tag, s.Tag, p.in)
+
+		if p.r != "" {
+			a.Equal(p.r, s.Range.String(), p.in)
+		} else if p.in != "" {
+			a.Nil(s.Range, p.in)
+		}
+
+		if p.kind == "invalid" {
+			a.Error(s.Err, p.in)
+		} else {
+			a.NoError(s.Err, p.in)
+		}
+	}
+}
+
+const manifest = `{
+	"name": "example",
+	"version": "1.0.0",
+	"dependencies": {
+		"lodash": "^4.17.21",
+		"left-pad": "*",
+		"broken": ">2.0.0 <1.0.0",
+		"aliased": "npm:other@>=0",
+		"local": "file:../local",
+		"shared": "workspace:*",
+		"odd": "not a range!"
+	},
+	"devDependencies": {
+		"typescript": "~5.4",
+		"eslint": "latest"
+	},
+	"peerDependencies": {
+		"react": ">=17 || 16.x"
+	},
+	"optionalDependencies": {
+		"fsevents": "git+https://github.com/fsevents/fsevents.git#semver:>=0"
+	},
+	"engines": {
+		"node": ">=18",
+		"npm": ""
+	}
+}`
+
+func TestParse(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := Parse(strings.NewReader(manifest))
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("example", m.Name)
+	a.Equal("1.0.0", m.Version)
+
+	var l []string
+	for _, d := range m.Dependencies {
+		l = append(l, string(d.Type)+" "+d.Name+" "+string(d.Kind))
+	}
+
+	a.Equal([]string{
+		"dependencies aliased alias",
+		"dependencies broken range",
+		"dependencies left-pad range",
+		"dependencies local file",
+		"dependencies lodash range",
+		"dependencies odd invalid",
+		"dependencies shared workspace",
+		"devDependencies eslint tag",
+		"devDependencies typescript range",
+		"peerDependencies react range",
+		"optionalDependencies fsevents git",
+		"engines node range",
+		"engines npm range",
+	}, l)
+
+	var findings []string
+	for _, f := range m.Audit() {
+		findings = append(findings, string(f.Problem)+" "+f.String())
+	}
+
+	a.Equal([]string{
+		`broad dependencies aliased "npm:other@>=0": every version satisfies the range`,
+		`unsatisfiable dependencies broken ">2.0.0 <1.0.0": no version satisfies >2.0.0 <1.0.0`,
+		`broad dependencies left-pad "*": every version satisfies the range`,
+		`invalid dependencies odd "not a range!": not a range!: invalid major version`,
+		`broad optionalDependencies fsevents "git+https://github.com/fsevents/fsevents.git#semver:>=0": every version satisfies the range`,
+		`broad engines npm "": every version satisfies the range`,
+	}, findings)
+
+	_, err = Parse(strings.NewReader(`{"dependencies": {"x": 1}}`))
+	a.Error(err)
+}
@@ -0,0 +1,460 @@
+package semver
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidMajor      = errors.New("invalid major version")
+	errInvalidMinor      = errors.New("invalid minor version")
+	errInvalidPatch      = errors.New("invalid patch version")
+	errMajorPeriod       = errors.New("major version should be followed by a period")
+	errMinorPeriod       = errors.New("minor version should be followed by a period")
+	errInvalidPrerelease = errors.New("invalid prerelease component")
+	errJunkAfterVersion  = errors.New("junk data after version")
+	errBoundOutOfRange   = errors.New("upper bound of range is out of range")
+	errPartialNE         = errors.New("!= requires a complete version")
+)
+
+// scanner walks a version or range string one byte at a time. Everything the
+// grammar accepts is ASCII, so there's no need to decode runes.
+type scanner[T string | []byte] struct {
+	s   T
+	pos int
+}
+
+func (p *scanner[T]) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *scanner[T]) accept(valid string) bool {
+	if p.pos < len(p.s) && strings.IndexByte(valid, p.s[p.pos]) != -1 {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *scanner[T]) acceptRun(valid string) int {
+	n := 0
+
+	for p.accept(valid) {
+		n++
+	}
+
+	return n
+}
+
+func (p *scanner[T]) acceptString(s string) bool {
+	if len(p.s)-p.pos < len(s) {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if p.s[p.pos+i] != s[i] {
+			return false
+		}
+	}
+
+	p.pos += len(s)
+
+	return true
+}
+
+// number consumes a run of digits and returns its value, saturating at
+// limit. The final return value reports whether the value overflowed.
+func (p *scanner[T]) number(limit int64) (int64, bool, bool) {
+	start := p.pos
+	if p.acceptRun("0123456789") == 0 {
+		return 0, false, false
+	}
+
+	var n int64
+	for i := start; i < p.pos; i++ {
+		d := int64(p.s[i] - '0')
+
+		if n > (limit-d)/10 {
+			return limit, true, true
+		}
+
+		n = n*10 + d
+	}
+
+	return n, true, false
+}
+
+// numberError reproduces the error strconv would have returned for the
+// digits just before pos.
+func (p *scanner[T]) numberError(start, bitSize int) error {
+	_, err := strconv.ParseInt(string(p.s[start:p.pos]), 10, bitSize)
+
+	return err
+}
+
+func (p *scanner[T]) versionNumber(field *int64, invalid error) error {
+	start := p.pos
+
+	n, ok, overflow := p.number(math.MaxInt32)
+	if !ok {
+		return invalid
+	}
+
+	if overflow {
+		return p.numberError(start, 32)
+	}
+
+	*field = n
+
+	return nil
+}
+
+func (p *scanner[T]) tags(l *[]string) error {
+	for {
+		start := p.pos
+
+		if p.acceptRun(tagchars) == 0 {
+			return errInvalidPrerelease
+		}
+
+		*l = append(*l, string(p.s[start:p.pos]))
+
+		if !p.accept(".") {
+			return nil
+		}
+	}
+}
+
+func parseVersion[T string | []byte](s T) (Version, error) {
+	var v Version
+
+	p := scanner[T]{s: s}
+
+	p.acceptRun(whitespace)
+	p.accept("vV")
+	p.acceptRun(whitespace)
+
+	if err := p.versionNumber(&v.Major, errInvalidMajor); err != nil {
+		return v, err
+	}
+
+	if !p.accept(".") {
+		return v, errMajorPeriod
+	}
+
+	if err := p.versionNumber(&v.Minor, errInvalidMinor); err != nil {
+		return v, err
+	}
+
+	if !p.accept(".") {
+		return v, errMinorPeriod
+	}
+
+	if err := p.versionNumber(&v.Patch, errInvalidPatch); err != nil {
+		return v, err
+	}
+
+	if p.accept("-") {
+		if err := p.tags(&v.Prerelease); err != nil {
+			return v, err
+		}
+	}
+
+	if p.accept("+") {
+		if err := p.tags(&v.Build); err != nil {
+			return v, err
+		}
+	}
+
+	if !p.eof() {
+		return v, errJunkAfterVersion
+	}
+
+	return v, nil
+}
+
+func ParseVersion(ver string) (Version, error) {
+	return parseVersion(ver)
+}
+
+func ParseVersionBytes(ver []byte) (Version, error) {
+	return parseVersion(ver)
+}
+
+// partialVersion is a version as written in a range, where any of the
+// numeric parts may be missing or a wildcard.
+type partialVersion struct {
+	hasMajor, hasMinor, hasPatch bool
+	major, minor, patch          int64
+	prerelease, build            []string
+}
+
+func (p *scanner[T]) partialNumber(has *bool, field *int64, invalid error) error {
+	if p.accept("*xX") {
+		return nil
+	}
+
+	start := p.pos
+
+	n, ok, overflow := p.number(math.MaxInt64)
+	if !ok {
+		return invalid
+	}
+
+	if overflow {
+		return p.numberError(start, 64)
+	}
+
+	*has, *field = true, n
+
+	return nil
+}
+
+// partialTags is like tags, except that a "*" component ends the version.
+func (p *scanner[T]) partialTags(l *[]string) (bool, error) {
+	for {
+		if p.accept("*") {
+			return true, nil
+		}
+
+		start := p.pos
+
+		if p.acceptRun(tagchars) == 0 {
+			return false, errInvalidPrerelease
+		}
+
+		*l = append(*l, string(p.s[start:p.pos]))
+
+		if !p.accept(".") {
+			return false, nil
+		}
+	}
+}
+
+func (p *scanner[T]) partialVersion() (partialVersion, error) {
+	var v partialVersion
+
+	p.accept("vV")
+
+	if err := p.partialNumber(&v.hasMajor, &v.major, errInvalidMajor); err != nil {
+		return v, err
+	}
+
+	if !p.accept(".") {
+		return v, nil
+	}
+
+	if err := p.partialNumber(&v.hasMinor, &v.minor, errInvalidMinor); err != nil {
+		return v, err
+	}
+
+	if !p.accept(".") {
+		return v, nil
+	}
+
+	if err := p.partialNumber(&v.hasPatch, &v.patch, errInvalidPatch); err != nil {
+		return v, err
+	}
+
+	if p.accept("-") {
+		if done, err := p.partialTags(&v.prerelease); err != nil || done {
+			return v, err
+		}
+	}
+
+	if p.accept("+") {
+		if _, err := p.partialTags(&v.build); err != nil {
+			return v, err
+		}
+	}
+
+	return v, nil
+}
+
+func (v partialVersion) version() Version {
+	return Version{
+		Major:      v.major,
+		Minor:      v.minor,
+		Patch:      v.patch,
+		Prerelease: v.prerelease,
+		Build:      v.build,
+	}
+}
+
+func increment(n *int64) error {
+	if *n == math.MaxInt64 {
+		return errBoundOutOfRange
+	}
+
+	*n++
+
+	return nil
+}
+
+// comparators expands a partial version and its operator into the
+// comparators it stands for, appending them to s.
+func (v partialVersion) comparators(operator Operator, s Set) (Set, error) {
+	switch operator {
+	case OperatorTilde:
+		c1 := Comparator{Operator: OperatorGTE, Version: v.version()}
+		c2 := Comparator{Operator: OperatorLT, Version: v.version()}
+
+		var err error
+
+		switch {
+		case v.hasPatch:
+			err = increment(&c2.Version.Minor)
+		case v.hasMinor:
+			err = increment(&c2.Version.Minor)
+		case v.hasMajor:
+			err = increment(&c2.Version.Major)
+		}
+
+		return append(s, c1, c2), err
+	case OperatorCaret:
+		if !v.hasMajor {
+			return append(s, Comparator{Operator: OperatorGTE}), nil
+		}
+
+		c1 := Comparator{Operator: OperatorGTE, Version: v.version()}
+		c2 := Comparator{
+			Operator: OperatorLT,
+			Version: Version{
+				Major: v.major,
+				Minor: v.minor,
+				Patch: v.patch,
+			},
+		}
+
+		var err error
+
+		switch {
+		case v.major != 0:
+			err = increment(&c2.Version.Major)
+			c2.Version.Minor = 0
+			c2.Version.Patch = 0
+		case v.minor != 0:
+			err = increment(&c2.Version.Minor)
+			c2.Version.Patch = 0
+		case v.patch != 0:
+			err = increment(&c2.Version.Patch)
+		case !v.hasMinor:
+			err = increment(&c2.Version.Major)
+		case !v.hasPatch:
+			err = increment(&c2.Version.Minor)
+		default:
+			err = increment(&c2.Version.Patch)
+		}
+
+		return append(s, c1, c2), err
+	case OperatorLT, OperatorLTE, OperatorGT, OperatorGTE:
+		return append(s, Comparator{Operator: operator, Version: v.version()}), nil
+	case OperatorNE:
+		if !v.hasMajor || !v.hasMinor || !v.hasPatch {
+			return s, errPartialNE
+		}
+
+		return append(s, Comparator{Operator: operator, Version: v.version()}), nil
+	}
+
+	switch {
+	case v.hasMajor && v.hasMinor && v.hasPatch:
+		return append(s, Comparator{Operator: operator, Version: v.version()}), nil
+	case !v.hasMajor:
+		return append(s, Comparator{Operator: OperatorGTE}), nil
+	}
+
+	c1 := Comparator{Operator: OperatorGTE, Version: v.version()}
+	c2 := Comparator{Operator: OperatorLT, Version: v.version()}
+
+	var err error
+
+	switch {
+	case v.hasPatch:
+		err = increment(&c2.Version.Minor)
+	case v.hasMinor:
+		err = increment(&c2.Version.Minor)
+	case v.hasMajor:
+		err = increment(&c2.Version.Major)
+	}
+
+	return append(s, c1, c2), err
+}
+
+func (p *scanner[T]) operator() Operator {
+	switch {
+	case p.accept("^"):
+		return OperatorCaret
+	case p.accept("~"):
+		return OperatorTilde
+	case p.acceptString("="):
+		return OperatorEQ
+	case p.acceptString("!="):
+		return OperatorNE
+	case p.acceptString(">="):
+		return OperatorGTE
+	case p.acceptString("<="):
+		return OperatorLTE
+	case p.accept(">"):
+		return OperatorGT
+	case p.accept("<"):
+		return OperatorLT
+	}
+
+	return OperatorNone
+}
+
+func ParseRange(ver string) (Range, error) {
+	p := scanner[string]{s: ver}
+
+	var r Range
+	var s Set
+
+	for {
+		p.acceptRun(whitespace)
+
+		if p.eof() {
+			return append(r, s), nil
+		}
+
+		operator := p.operator()
+
+		p.acceptRun(whitespace)
+
+		v, err := p.partialVersion()
+		if err != nil {
+			return nil, err
+		}
+
+		if s, err = v.comparators(operator, s); err != nil {
+			return nil, err
+		}
+
+		p.acceptRun(whitespace)
+
+		if p.acceptString("-") {
+			s[len(s)-1].Operator = OperatorGTE
+
+			p.acceptRun(whitespace)
+
+			v, err := p.partialVersion()
+			if err != nil {
+				return nil, err
+			}
+
+			if s, err = v.comparators(OperatorLTE, s); err != nil {
+				return nil, err
+			}
+		}
+
+		p.acceptRun(whitespace)
+
+		if p.acceptString("||") {
+			r = append(r, s)
+			s = nil
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Package policy decides whether a dependency may move from one version to
+// another, following declarative rules written in YAML or JSON.
+//
+// A policy looks like this:
+//
+//	default: review
+//	rules:
+//	  - name: no-prereleases
+//	    to_prerelease: true
+//	    action: deny
+//	  - name: core-majors
+//	    packages: ["core-*"]
+//	    update: [major, premajor]
+//	    action: deny
+//	  - name: patches
+//	    update: [patch]
+//	    action: allow
+//
+// Rules are tried in order and the first that matches decides. If none
+// match, the default applies, which is review unless the policy says
+// otherwise.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/deoxxa/semver"
+)
+
+type Action string
+
+const (
+	Allow  Action = "allow"
+	Deny   Action = "deny"
+	Review Action = "review"
+)
+
+func (a Action) valid() bool {
+	return a == Allow || a == Deny || a == Review
+}
+
+var releaseTypes = map[semver.ReleaseType]bool{
+	semver.ReleaseMajor:      true,
+	semver.ReleasePremajor:   true,
+	semver.ReleaseMinor:      true,
+	semver.ReleasePreminor:   true,
+	semver.ReleasePatch:      true,
+	semver.ReleasePrepatch:   true,
+	semver.ReleasePrerelease: true,
+	semver.ReleaseBuild:      true,
+}
+
+// Rule matches an update when every condition it sets holds. Packages are
+// path.Match patterns, so "*" doesn't cross a "/" and "@acme/*" matches
+// every package in a scope. Update lists the kinds of change, as classified
+// by semver.Diff, and From and To are ranges the versions must satisfy.
+type Rule struct {
+	Name         string               `json:"name" yaml:"name"`
+	Packages     []string             `json:"packages,omitempty" yaml:"packages,omitempty"`
+	Update       []semver.ReleaseType `json:"update,omitempty" yaml:"update,omitempty"`
+	From         string               `json:"from,omitempty" yaml:"from,omitempty"`
+	To           string               `json:"to,omitempty" yaml:"to,omitempty"`
+	ToPrerelease *bool                `json:"to_prerelease,omitempty" yaml:"to_prerelease,omitempty"`
+	Downgrade    *bool                `json:"downgrade,omitempty" yaml:"downgrade,omitempty"`
+	Action       Action               `json:"action" yaml:"action"`
+
+	from, to *semver.Matcher
+	compiled bool
+}
+
+func (r *Rule) compile() error {
+	if !r.Action.valid() {
+		return fmt.Errorf("invalid action %q", r.Action)
+	}
+
+	for _, p := range r.Packages {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("package %q: %w", p, err)
+		}
+	}
+
+	for _, t := range r.Update {
+		if !releaseTypes[t] {
+			return fmt.Errorf("invalid update %q", t)
+		}
+	}
+
+	for _, f := range []struct {
+		s string
+		m **semver.Matcher
+	}{{r.From, &r.from}, {r.To, &r.to}} {
+		if f.s == "" {
+			continue
+		}
+
+		rng, err := semver.ParseRange(f.s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.s, err)
+		}
+
+		*f.m = rng.Compile()
+	}
+
+	r.compiled = true
+
+	return nil
+}
+
+func (r *Rule) matches(pkg string, from, to semver.Version, update semver.ReleaseType) bool {
+	if len(r.Packages) > 0 {
+		ok := false
+
+		for _, p := range r.Packages {
+			if m, _ := path.Match(p, pkg); m {
+				ok = true
+				break
+			}
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	if len(r.Update) > 0 {
+		ok := false
+
+		for _, t := range r.Update {
+			if t == update {
+				ok = true
+				break
+			}
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	if r.from != nil && !r.from.SatisfiedBy(from) {
+		return false
+	}
+
+	if r.to != nil && !r.to.SatisfiedBy(to) {
+		return false
+	}
+
+	if r.ToPrerelease != nil && *r.ToPrerelease != (len(to.Prerelease) > 0) {
+		return false
+	}
+
+	if r.Downgrade != nil && *r.Downgrade != to.LessThan(from) {
+		return false
+	}
+
+	return true
+}
+
+type Policy struct {
+	Default Action `json:"default,omitempty" yaml:"default,omitempty"`
+	Rules   []Rule `json:"rules" yaml:"rules"`
+}
+
+// Parse reads a policy from YAML, or from JSON, which YAML includes.
+// Unknown fields are an error, so that a misspelled condition can't
+// silently widen a rule.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+
+	d := yaml.NewDecoder(bytes.NewReader(data))
+	d.KnownFields(true)
+
+	if err := d.Decode(&p); err != nil {
+		return nil, err
+	}
+
+	if err := p.Compile(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// Compile checks every rule in p and prepares it for evaluation, and sets
+// an empty Default to review. Parse does this itself; a Policy built or
+// changed in code should be compiled again before it's shared, as Evaluate
+// otherwise has to check every rule each time it runs.
+func (p *Policy) Compile() error {
+	if p.Default == "" {
+		p.Default = Review
+	}
+
+	if !p.Default.valid() {
+		return fmt.Errorf("invalid default action %q", p.Default)
+	}
+
+	var errs []error
+
+	for i := range p.Rules {
+		if err := p.Rules[i].compile(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Rules[i].name(i), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Rule) name(i int) string {
+	if r.Name == "" {
+		return fmt.Sprintf("rule %d", i+1)
+	}
+
+	return r.Name
+}
+
+func ReadFile(name string) (*Policy, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return p, nil
+}
+
+// Decision is the outcome of evaluating an update. Rule is the rule that
+// decided it, or nil if the policy's default did.
+type Decision struct {
+	Action Action
+	Rule   *Rule
+	Update semver.ReleaseType
+}
+
+// Evaluate decides whether pkg may move from one version to another. It
+// fails if p has an invalid rule, rather than skipping it, so a bad rule
+// can't let through an update it was meant to stop.
+func (p *Policy) Evaluate(pkg string, from, to semver.Version) (Decision, error) {
+	update := semver.Diff(from, to)
+
+	for i := range p.Rules {
+		r := &p.Rules[i]
+
+		if !r.compiled {
+			c := *r
+			if err := c.compile(); err != nil {
+				return Decision{}, fmt.Errorf("%s: %w", r.name(i), err)
+			}
+
+			r = &c
+		}
+
+		if r.matches(pkg, from, to, update) {
+			return Decision{Action: r.Action, Rule: &p.Rules[i], Update: update}, nil
+		}
+	}
+
+	switch {
+	case p.Default == "":
+		return Decision{Action: Review, Update: update}, nil
+	case !p.Default.valid():
+		return Decision{}, fmt.Errorf("invalid default action %q", p.Default)
+	}
+
+	return Decision{Action: p.Default, Update: update}, nil
+}
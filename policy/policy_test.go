@@ -0,0 +1,160 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+func TestEvaluate(t *testing.T) {
+	a := assert.New(t)
+
+	p, err := ReadFile("testdata/policy.yaml")
+	if !a.NoError(err) {
+		return
+	}
+
+	pairs := []struct {
+		pkg, from, to string
+		action        Action
+		rule          string
+		update        semver.ReleaseType
+	}{
+		{"lodash", "4.17.20", "4.17.21", Allow, "auto-merge-patch", semver.ReleasePatch},
+		{"lodash", "4.17.21", "4.17.21+build.1", Allow, "auto-merge-patch", semver.ReleaseBuild},
+		{"lodash", "4.16.0", "4.17.0", Review, "review-minor", semver.ReleaseMinor},
+		{"lodash", "3.10.1", "4.0.0", Review, "", semver.ReleaseMajor},
+		{"lodash", "4.17.21", "5.0.0-rc.1", Deny, "no-prereleases-in-prod", semver.ReleasePremajor},
+		{"lodash", "4.17.21", "4.17.20", Review, "no-downgrades", semver.ReleasePatch},
+		{"core-auth", "1.4.0", "2.0.0", Deny, "block-core-majors", semver.ReleaseMajor},
+		{"core-auth", "1.4.0", "1.4.1", Allow, "auto-merge-patch", semver.ReleasePatch},
+		{"@acme/core-db", "1.4.0", "2.0.0", Deny, "block-core-majors", semver.ReleaseMajor},
+		{"@acme/ui", "1.4.0", "2.0.0", Review, "", semver.ReleaseMajor},
+		{"legacy-client", "2.9.0", "3.0.0", Deny, "pin-legacy-client", semver.ReleaseMajor},
+		{"legacy-client", "2.9.0", "2.9.1", Allow, "auto-merge-patch", semver.ReleasePatch},
+	}
+
+	for _, c := range pairs {
+		from, err := semver.ParseVersion(c.from)
+		a.NoError(err)
+		to, err := semver.ParseVersion(c.to)
+		a.NoError(err)
+
+		d, err := p.Evaluate(c.pkg, from, to)
+		a.NoError(err)
+
+		a.Equal(c.action, d.Action, "%s %s -> %s", c.pkg, c.from, c.to)
+		a.Equal(c.update, d.Update, "%s %s -> %s", c.pkg, c.from, c.to)
+
+		if c.rule == "" {
+			a.Nil(d.Rule, "%s %s -> %s", c.pkg, c.from, c.to)
+		} else if a.NotNil(d.Rule, "%s %s -> %s", c.pkg, c.from, c.to) {
+			a.Equal(c.rule, d.Rule.Name, "%s %s -> %s", c.pkg, c.from, c.to)
+		}
+	}
+}
+
+func TestEvaluateJSON(t *testing.T) {
+	a := assert.New(t)
+
+	p, err := ReadFile("testdata/policy.json")
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal(Deny, p.Default)
+
+	pairs := []struct {
+		from, to string
+		action   Action
+	}{
+		{"0.2.0", "0.2.1", Review},
+		{"0.2.0", "0.3.0", Review},
+		{"1.2.0", "1.2.1", Allow},
+		{"1.2.0", "1.3.0", Deny},
+	}
+
+	for _, c := range pairs {
+		from, err := semver.ParseVersion(c.from)
+		a.NoError(err)
+		to, err := semver.ParseVersion(c.to)
+		a.NoError(err)
+
+		d, err := p.Evaluate("pkg", from, to)
+		a.NoError(err)
+		a.Equal(c.action, d.Action, "%s -> %s", c.from, c.to)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	a := assert.New(t)
+
+	p, err := Parse([]byte("rules: []"))
+	if a.NoError(err) {
+		a.Equal(Review, p.Default)
+	}
+
+	for _, s := range []string{
+		"default: maybe",
+		"rules: [{action: block}]",
+		"rules: [{name: x, update: [huge], action: deny}]",
+		"rules: [{name: x, to: \">=1.x.y\", action: deny}]",
+		"rules: [{name: x, packages: [\"[\"], action: deny}]",
+		"rules: [{name: x, updates: [major], action: deny}]",
+		"{",
+	} {
+		_, err := Parse([]byte(s))
+		a.Error(err, s)
+	}
+
+	_, err = Parse([]byte("rules: [{action: allow}, {name: bad, action: nope}, {action: stop}]"))
+	if a.Error(err) {
+		a.Equal("bad: invalid action \"nope\"\nrule 3: invalid action \"stop\"", err.Error())
+	}
+}
+
+func TestEvaluateUncompiled(t *testing.T) {
+	a := assert.New(t)
+
+	v := func(s string) semver.Version {
+		v, err := semver.ParseVersion(s)
+		a.NoError(err)
+		return v
+	}
+
+	p := &Policy{Rules: []Rule{{Name: "majors", To: ">=3.0.0", Action: Deny}}}
+
+	d, err := p.Evaluate("pkg", v("1.0.0"), v("1.1.0"))
+	if a.NoError(err) {
+		a.Equal(Review, d.Action)
+		a.Nil(d.Rule)
+	}
+
+	d, err = p.Evaluate("pkg", v("2.0.0"), v("3.0.0"))
+	if a.NoError(err) {
+		a.Equal(Deny, d.Action)
+		a.Equal(&p.Rules[0], d.Rule)
+	}
+
+	p.Rules = append(p.Rules, Rule{Name: "bad", From: ">=1.x.y", Action: Allow})
+
+	_, err = p.Evaluate("pkg", v("1.0.0"), v("1.1.0"))
+	a.Error(err)
+	a.Error(p.Compile())
+
+	p = &Policy{Default: "maybe"}
+
+	_, err = p.Evaluate("pkg", v("1.0.0"), v("1.1.0"))
+	a.Error(err)
+
+	p = &Policy{Rules: []Rule{{To: ">=3.0.0", Action: Deny}}}
+	if a.NoError(p.Compile()) {
+		a.Equal(Review, p.Default)
+
+		d, err = p.Evaluate("pkg", v("1.0.0"), v("1.1.0"))
+		a.NoError(err)
+		a.Equal(Review, d.Action)
+	}
+}
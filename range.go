@@ -1,171 +1,9 @@
 package semver
 
 import (
-	"strconv"
 	"strings"
-
-	"go.bmatsuo.co/go-lexer"
 )
 
-func stateRange(l *lexer.Lexer) lexer.StateFn {
-	if l.AcceptRun(whitespace) > 0 {
-		l.Emit(ItemWhitespace)
-	}
-
-	if lexer.IsEOF(l.Peek()) {
-		return nil
-	}
-
-	switch {
-	case l.Accept("^"):
-		l.Emit(ItemCaret)
-	case l.Accept("~"):
-		l.Emit(ItemTilde)
-	case l.AcceptString("="):
-		l.Emit(ItemEQ)
-	case l.AcceptString(">="):
-		l.Emit(ItemGTE)
-	case l.AcceptString("<="):
-		l.Emit(ItemLTE)
-	case l.Accept(">"):
-		l.Emit(ItemGT)
-	case l.Accept("<"):
-		l.Emit(ItemLT)
-	}
-
-	if l.AcceptRun(whitespace) > 0 {
-		l.Emit(ItemWhitespace)
-	}
-
-	if fn := lexPartialVersion(l); fn != nil {
-		return fn
-	}
-
-	if l.AcceptRun(whitespace) > 0 {
-		l.Emit(ItemWhitespace)
-	}
-
-	if l.AcceptString("-") {
-		l.Emit(ItemDash)
-
-		if l.AcceptRun(whitespace) > 0 {
-			l.Emit(ItemWhitespace)
-		}
-
-		if fn := lexPartialVersion(l); fn != nil {
-			return fn
-		}
-	}
-
-	if l.AcceptRun(whitespace) > 0 {
-		l.Emit(ItemWhitespace)
-	}
-
-	if l.AcceptString("||") {
-		l.Emit(ItemPipe)
-	}
-
-	return stateRange
-}
-
-func lexPartialVersion(l *lexer.Lexer) lexer.StateFn {
-	if l.Accept("vV") {
-		l.Ignore()
-	}
-
-	if l.Accept("*xX") {
-		l.Emit(ItemMajor)
-	} else if l.AcceptRun("0123456789") > 0 {
-		l.Emit(ItemMajor)
-	} else {
-		return l.Errorf("invalid major version")
-	}
-
-	if l.Accept(".") {
-		l.Emit(ItemPeriod)
-	} else {
-		l.Emit(ItemComplete)
-
-		return nil
-	}
-
-	if l.Accept("*xX") {
-		l.Emit(ItemMinor)
-	} else if l.AcceptRun("0123456789") > 0 {
-		l.Emit(ItemMinor)
-	} else {
-		return l.Errorf("invalid minor version")
-	}
-
-	if l.Accept(".") {
-		l.Emit(ItemPeriod)
-	} else {
-		l.Emit(ItemComplete)
-
-		return nil
-	}
-
-	if l.Accept("*xX") {
-		l.Emit(ItemPatch)
-	} else if l.AcceptRun("0123456789") > 0 {
-		l.Emit(ItemPatch)
-	} else {
-		return l.Errorf("invalid patch version")
-	}
-
-	if l.Accept("-") {
-		l.Ignore()
-
-		for {
-			if l.Accept("*") {
-				l.Emit(ItemComplete)
-
-				return nil
-			}
-
-			if l.AcceptRun(tagchars) == 0 {
-				return l.Errorf("invalid prerelease component")
-			} else {
-				l.Emit(ItemPrerelease)
-			}
-
-			if l.Accept(".") {
-				l.Emit(ItemPeriod)
-			} else {
-				break
-			}
-		}
-	}
-
-	if l.Accept("+") {
-		l.Ignore()
-
-		for {
-			if l.Accept("*") {
-				l.Emit(ItemComplete)
-
-				return nil
-			}
-
-			if l.AcceptRun(tagchars) == 0 {
-				return l.Errorf("invalid prerelease component")
-			} else {
-				l.Emit(ItemBuild)
-			}
-
-			if l.Accept(".") {
-				l.Emit(ItemPeriod)
-			} else {
-				break
-			}
-		}
-	}
-
-	l.Emit(ItemComplete)
-
-	return nil
-}
-
 type Operator string
 
 const (
@@ -177,6 +15,7 @@ const (
 	OperatorGT             = ">"
 	OperatorGTE            = ">="
 	OperatorEQ             = "="
+	OperatorNE             = "!="
 )
 
 type Comparator struct {
@@ -189,43 +28,28 @@ func (c Comparator) String() string {
 }
 
 func (c Comparator) SatisfiedBy(v Version) bool {
+	n := comparePrecedence(v, c.Version)
+
 	switch c.Operator {
-	case OperatorNone:
-		fallthrough
-	case OperatorEQ:
-		if v.Major != c.Version.Major {
-			return false
-		}
-		if v.Minor != c.Version.Minor {
-			return false
-		}
-		if v.Patch != c.Version.Patch {
-			return false
-		}
-		if len(v.Prerelease) != len(c.Version.Prerelease) {
-			return false
-		}
-		if len(v.Build) != len(c.Version.Build) {
-			return false
-		}
-		return true
-	case OperatorGT, OperatorGTE:
-		if v.LessThan(c.Version) {
-			return false
-		} else {
-			return v.GreaterThan(c.Version) || c.Operator == OperatorGTE
-		}
-	case OperatorLT, OperatorLTE:
-		if v.GreaterThan(c.Version) {
-			return false
-		} else {
-			return v.LessThan(c.Version) || c.Operator == OperatorLTE
-		}
+	case OperatorNone, OperatorEQ:
+		return n == 0
+	case OperatorNE:
+		return n != 0
+	case OperatorGT:
+		return n > 0
+	case OperatorGTE:
+		return n >= 0
+	case OperatorLT:
+		return n < 0
+	case OperatorLTE:
+		return n <= 0
 	}
 
 	return false
 }
 
+// Set is satisfied by a version that satisfies every one of its
+// comparators, so an empty (or nil) Set is satisfied by every version.
 type Set []Comparator
 
 func (s Set) String() string {
@@ -248,9 +72,19 @@ func (s Set) SatisfiedBy(v Version) bool {
 	return true
 }
 
+// Range is satisfied by a version that satisfies any one of its Sets. That
+// makes an empty Range, with no Sets at all, satisfied by nothing, while a
+// Range holding a single empty Set (which is what ParseRange returns for "")
+// is satisfied by everything.
 type Range []Set
 
+// String renders r in a form ParseRange accepts. An empty Range is rendered
+// as "<0.0.0-0", which like the empty Range itself is satisfied by nothing.
 func (r Range) String() string {
+	if len(r) == 0 {
+		return OperatorLT + minVersion.String()
+	}
+
 	l := make([]string, len(r))
 
 	for i, v := range r {
@@ -270,235 +104,42 @@ func (r Range) SatisfiedBy(v Version) bool {
 	return false
 }
 
-func (r Range) BestMatch(l List) (Version, bool) {
-	for i := len(l) - 1; i > 0; i-- {
-		if r.SatisfiedBy(l[i]) {
-			return l[i], true
-		}
-	}
-
-	return Version{}, false
+// Complement returns a Range satisfied by exactly the versions that r is not
+// satisfied by.
+func (r Range) Complement() Range {
+	return r.Compile().complement().Range()
 }
 
-func ParseRange(ver string) (Range, error) {
-	l := lexer.New(stateRange, ver)
-
-	var r Range
-	var s Set
-
-	var (
-		operator                     Operator
-		hasMajor, hasMinor, hasPatch bool
-		major, minor, patch          int64
-		prerelease, build            []string
-	)
-
-	for {
-		t := l.Next()
-
-		if t.Type == lexer.ItemError {
-			return nil, t.Err()
-		}
-
-		switch t.Type {
-		case ItemWhitespace:
-			continue
-		case ItemPeriod:
-			continue
-		case ItemTilde:
-			operator = OperatorTilde
-			continue
-		case ItemCaret:
-			operator = OperatorCaret
-			continue
-		case ItemEQ:
-			operator = OperatorEQ
-			continue
-		case ItemLT:
-			operator = OperatorLT
-			continue
-		case ItemGT:
-			operator = OperatorGT
-			continue
-		case ItemLTE:
-			operator = OperatorLTE
-			continue
-		case ItemGTE:
-			operator = OperatorGTE
-			continue
-		case ItemMajor:
-			if t.Value != "*" && t.Value != "x" && t.Value != "X" {
-				hasMajor = true
-				major, _ = strconv.ParseInt(t.Value, 10, 64)
-			}
-			continue
-		case ItemMinor:
-			if t.Value != "*" && t.Value != "x" && t.Value != "X" {
-				hasMinor = true
-				minor, _ = strconv.ParseInt(t.Value, 10, 64)
-			}
-			continue
-		case ItemPatch:
-			if t.Value != "*" && t.Value != "x" && t.Value != "X" {
-				hasPatch = true
-				patch, _ = strconv.ParseInt(t.Value, 10, 64)
-			}
-			continue
-		case ItemPrerelease:
-			prerelease = append(prerelease, t.Value)
-			continue
-		case ItemBuild:
-			build = append(build, t.Value)
-			continue
-		case ItemComplete:
-			switch operator {
-			case OperatorTilde:
-				c1 := Comparator{
-					Operator: OperatorGTE,
-					Version: Version{
-						Major:      major,
-						Minor:      minor,
-						Patch:      patch,
-						Prerelease: prerelease,
-						Build:      build,
-					},
-				}
-				c2 := Comparator{
-					Operator: OperatorLT,
-					Version: Version{
-						Major:      major,
-						Minor:      minor,
-						Patch:      patch,
-						Prerelease: prerelease,
-						Build:      build,
-					},
-				}
-
-				switch {
-				case hasPatch:
-					c2.Version.Minor++
-				case hasMinor:
-					c2.Version.Minor++
-				case hasMajor:
-					c2.Version.Major++
-				}
-
-				s = append(s, c1, c2)
-			case OperatorCaret:
-				c1 := Comparator{
-					Operator: OperatorGTE,
-					Version: Version{
-						Major:      major,
-						Minor:      minor,
-						Patch:      patch,
-						Prerelease: prerelease,
-						Build:      build,
-					},
-				}
-				c2 := Comparator{
-					Operator: OperatorLT,
-					Version: Version{
-						Major: major,
-						Minor: minor,
-						Patch: patch,
-					},
-				}
-
-				switch {
-				case major != 0:
-					c2.Version.Major++
-					c2.Version.Minor = 0
-					c2.Version.Patch = 0
-				case minor != 0:
-					c2.Version.Minor++
-					c2.Version.Patch = 0
-				case patch != 0:
-					c2.Version.Patch++
-				}
-
-				s = append(s, c1, c2)
-			case OperatorLT, OperatorLTE, OperatorGT, OperatorGTE:
-				s = append(s, Comparator{
-					Operator: operator,
-					Version: Version{
-						Major:      major,
-						Minor:      minor,
-						Patch:      patch,
-						Prerelease: prerelease,
-						Build:      build,
-					},
-				})
-			default:
-				switch {
-				case hasMajor && hasMinor && hasPatch:
-					s = append(s, Comparator{
-						Operator: operator,
-						Version: Version{
-							Major:      major,
-							Minor:      minor,
-							Patch:      patch,
-							Prerelease: prerelease,
-							Build:      build,
-						},
-					})
-				case !hasMajor:
-					s = append(s, Comparator{
-						Operator: OperatorGTE,
-					})
-				default:
-					c1 := Comparator{
-						Operator: OperatorGTE,
-						Version: Version{
-							Major:      major,
-							Minor:      minor,
-							Patch:      patch,
-							Prerelease: prerelease,
-							Build:      build,
-						},
-					}
-					c2 := Comparator{
-						Operator: OperatorLT,
-						Version: Version{
-							Major:      major,
-							Minor:      minor,
-							Patch:      patch,
-							Prerelease: prerelease,
-							Build:      build,
-						},
-					}
-
-					switch {
-					case hasPatch:
-						c2.Version.Minor++
-					case hasMinor:
-						c2.Version.Minor++
-					case hasMajor:
-						c2.Version.Major++
-					}
+// Union returns a Range satisfied by any version that satisfies either r or
+// other, simplified to the fewest Sets that describe it.
+func (r Range) Union(other Range) Range {
+	return r.Compile().union(other.Compile()).Range()
+}
 
-					s = append(s, c1, c2)
-				}
-			}
-		case ItemDash:
-			s[len(s)-1].Operator = OperatorGTE
-			operator = OperatorLTE
-			continue
-		case ItemPipe:
-			r = append(r, s)
-			s = nil
-		}
+// Intersect returns a Range satisfied by the versions that satisfy both r and
+// other, simplified to the fewest Sets that describe it.
+func (r Range) Intersect(other Range) Range {
+	return r.Compile().intersect(other.Compile()).Range()
+}
 
-		operator = OperatorNone
-		hasMajor, hasMinor, hasPatch = false, false, false
-		major, minor, patch = 0, 0, 0
-		prerelease, build = nil, nil
+// Difference returns a Range satisfied by the versions that satisfy r but not
+// other, simplified to the fewest Sets that describe it.
+func (r Range) Difference(other Range) Range {
+	return r.Compile().intersect(other.Compile().complement()).Range()
+}
 
-		if t.Type == lexer.ItemEOF {
-			r = append(r, s)
+// Equal reports whether r and other are satisfied by exactly the same
+// versions, however they happen to be written.
+func (r Range) Equal(other Range) bool {
+	return r.Compile().equal(other.Compile())
+}
 
-			break
+func (r Range) BestMatch(l List) (Version, bool) {
+	for i := len(l) - 1; i >= 0; i-- {
+		if r.SatisfiedBy(l[i]) {
+			return l[i], true
 		}
 	}
 
-	return r, nil
+	return Version{}, false
 }
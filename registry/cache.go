@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/deoxxa/semver"
+)
+
+// Cache wraps a Registry, remembering successful lookups for TTL (forever if
+// TTL is zero) and coalescing concurrent lookups of the same package into a
+// single upstream request. Errors are never cached.
+//
+// The upstream request doesn't belong to any one caller, so it carries on
+// when the caller that started it gives up; it's limited by Timeout
+// instead, or DefaultTimeout if that's zero.
+type Cache struct {
+	Registry Registry
+	TTL      time.Duration
+	Timeout  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	done     chan struct{}
+	releases []Release
+	err      error
+	fetched  time.Time
+}
+
+const DefaultTimeout = 30 * time.Second
+
+func NewCache(r Registry, ttl time.Duration) *Cache {
+	return &Cache{Registry: r, TTL: ttl, Timeout: DefaultTimeout}
+}
+
+func (c *Cache) ListVersions(ctx context.Context, name string) (semver.List, error) {
+	releases, err := c.Releases(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return versions(releases), nil
+}
+
+func (c *Cache) Releases(ctx context.Context, name string) ([]Release, error) {
+	e := c.entry(ctx, name)
+
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	releases := make([]Release, len(e.releases))
+	copy(releases, e.releases)
+
+	return releases, nil
+}
+
+// entry returns the cache entry for name, starting an upstream request to
+// fill it in if there isn't a usable one.
+func (c *Cache) entry(ctx context.Context, name string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]*cacheEntry)
+	}
+
+	if e, ok := c.entries[name]; ok {
+		select {
+		case <-e.done:
+			if c.TTL <= 0 || time.Since(e.fetched) <= c.TTL {
+				return e
+			}
+		default:
+			return e
+		}
+	}
+
+	e := &cacheEntry{done: make(chan struct{})}
+	c.entries[name] = e
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+
+	go func() {
+		defer cancel()
+
+		type result struct {
+			releases []Release
+			err      error
+		}
+
+		// The entry is finished at the deadline even if the Registry
+		// ignores ctx, so that a hung request can't hold up every later
+		// lookup of the package.
+		ch := make(chan result, 1)
+
+		go func() {
+			releases, err := c.Registry.Releases(ctx, name)
+			ch <- result{releases, err}
+		}()
+
+		select {
+		case r := <-ch:
+			e.releases, e.err = r.releases, r.err
+		case <-ctx.Done():
+			e.err = ctx.Err()
+		}
+
+		e.fetched = time.Now()
+
+		if e.err != nil {
+			c.mu.Lock()
+			if c.entries[name] == e {
+				delete(c.entries, name)
+			}
+			c.mu.Unlock()
+		}
+
+		close(e.done)
+	}()
+
+	return e
+}
+
+func (c *Cache) Forget(name string) {
+	c.mu.Lock()
+	delete(c.entries, name)
+	c.mu.Unlock()
+}
@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deoxxa/semver"
+)
+
+// Dir is a registry laid out on disk as <root>/<name>/<version>/. A version
+// directory containing a DEPRECATED file is reported as deprecated, with the
+// file's contents as the message, and its modification time is used as the
+// publish time.
+type Dir string
+
+func (d Dir) ListVersions(ctx context.Context, name string) (semver.List, error) {
+	releases, err := d.Releases(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return versions(releases), nil
+}
+
+func (d Dir) Releases(ctx context.Context, name string) ([]Release, error) {
+	if name == "" || !filepath.IsLocal(filepath.FromSlash(name)) {
+		return nil, fmt.Errorf("%s: invalid package name", name)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(string(d), filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var releases []Release
+
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if !e.IsDir() {
+			continue
+		}
+
+		v, err := semver.ParseVersion(e.Name())
+		if err != nil {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		r := Release{
+			Version:   v,
+			Published: info.ModTime(),
+		}
+
+		if b, err := os.ReadFile(filepath.Join(string(d), filepath.FromSlash(name), e.Name(), "DEPRECATED")); err == nil {
+			if r.Deprecated = strings.TrimSpace(string(b)); r.Deprecated == "" {
+				r.Deprecated = "deprecated"
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		releases = append(releases, r)
+	}
+
+	sortReleases(releases)
+
+	return releases, nil
+}
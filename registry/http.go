@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/deoxxa/semver"
+)
+
+// HTTP reads npm-style packuments from <BaseURL>/<name>. Only the "versions"
+// and "time" fields are used; version keys that don't parse are skipped.
+type HTTP struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+type packument struct {
+	Versions map[string]struct {
+		Deprecated json.RawMessage `json:"deprecated"`
+	} `json:"versions"`
+	Time map[string]time.Time `json:"time"`
+}
+
+func (h *HTTP) ListVersions(ctx context.Context, name string) (semver.List, error) {
+	releases, err := h.Releases(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return versions(releases), nil
+}
+
+func (h *HTTP) Releases(ctx context.Context, name string) ([]Release, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%s: invalid package name", name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(h.BaseURL, "/")+"/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	c := h.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	case res.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s: unexpected status %s", name, res.Status)
+	}
+
+	var p packument
+	if err := json.NewDecoder(res.Body).Decode(&p); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	releases := make([]Release, 0, len(p.Versions))
+
+	for s, info := range p.Versions {
+		v, err := semver.ParseVersion(s)
+		if err != nil {
+			continue
+		}
+
+		r := Release{
+			Version:   v,
+			Published: p.Time[s],
+		}
+
+		var deprecated string
+		if json.Unmarshal(info.Deprecated, &deprecated) == nil {
+			r.Deprecated = deprecated
+		}
+
+		releases = append(releases, r)
+	}
+
+	sortReleases(releases)
+
+	return releases, nil
+}
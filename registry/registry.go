@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/deoxxa/semver"
+)
+
+var ErrNotFound = errors.New("package not found")
+
+type Release struct {
+	Version    semver.Version
+	Published  time.Time
+	Deprecated string
+}
+
+type Registry interface {
+	// ListVersions returns every known version of a package, sorted in
+	// ascending order so it can be handed straight to Range.BestMatch.
+	ListVersions(ctx context.Context, name string) (semver.List, error)
+	Releases(ctx context.Context, name string) ([]Release, error)
+}
+
+func versions(releases []Release) semver.List {
+	l := make(semver.List, len(releases))
+
+	for i, r := range releases {
+		l[i] = r.Version
+	}
+
+	return l
+}
+
+func sortReleases(releases []Release) {
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Version.LessThan(releases[j].Version)
+	})
+}
@@ -0,0 +1,295 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+const packumentFixture = `{
+  "name": "left-pad",
+  "versions": {
+    "1.0.0": {},
+    "1.10.0": {},
+    "1.2.0": {"deprecated": "use 1.3.0"},
+    "1.0.0-beta.1": {"deprecated": false},
+    "not-a-version": {}
+  },
+  "time": {
+    "created": "2016-01-01T00:00:00Z",
+    "1.0.0": "2016-01-02T00:00:00Z",
+    "1.2.0": "2016-02-02T00:00:00Z"
+  }
+}`
+
+func newServer(hits *int32, release chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+
+		if release != nil {
+			<-release
+		}
+
+		switch r.URL.EscapedPath() {
+		case "/left-pad", "/@scope%2Fleft-pad":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, packumentFixture)
+		case "/broken":
+			http.Error(w, "oops", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func versionStrings(l semver.List) []string {
+	r := make([]string, len(l))
+	for i, v := range l {
+		r[i] = v.String()
+	}
+
+	return r
+}
+
+func TestHTTP(t *testing.T) {
+	a := assert.New(t)
+
+	var hits int32
+	s := newServer(&hits, nil)
+	defer s.Close()
+
+	h := &HTTP{BaseURL: s.URL}
+
+	l, err := h.ListVersions(context.Background(), "left-pad")
+	a.NoError(err)
+	a.Equal([]string{"1.0.0-beta.1", "1.0.0", "1.2.0", "1.10.0"}, versionStrings(l))
+
+	r, err := semver.ParseRange("^1.0.0")
+	a.NoError(err)
+	v, ok := r.BestMatch(l)
+	a.True(ok)
+	a.Equal("1.10.0", v.String())
+
+	releases, err := h.Releases(context.Background(), "@scope/left-pad")
+	a.NoError(err)
+	a.Len(releases, 4)
+	a.Equal("", releases[0].Deprecated)
+	a.Equal("use 1.3.0", releases[2].Deprecated)
+	a.Equal(time.Date(2016, 2, 2, 0, 0, 0, 0, time.UTC), releases[2].Published)
+
+	_, err = h.ListVersions(context.Background(), "missing")
+	a.True(errors.Is(err, ErrNotFound))
+
+	_, err = h.ListVersions(context.Background(), "broken")
+	a.Error(err)
+}
+
+func TestDir(t *testing.T) {
+	a := assert.New(t)
+
+	root := t.TempDir()
+
+	for _, p := range []string{"foo/1.0.0", "foo/0.9.0", "foo/junk", "@scope/bar/2.0.0"} {
+		a.NoError(os.MkdirAll(filepath.Join(root, filepath.FromSlash(p)), 0755))
+	}
+	a.NoError(os.WriteFile(filepath.Join(root, "foo", "0.9.0", "DEPRECATED"), []byte("too old\n"), 0644))
+	a.NoError(os.WriteFile(filepath.Join(root, "foo", "README"), nil, 0644))
+
+	d := Dir(root)
+
+	l, err := d.ListVersions(context.Background(), "foo")
+	a.NoError(err)
+	a.Equal([]string{"0.9.0", "1.0.0"}, versionStrings(l))
+
+	releases, err := d.Releases(context.Background(), "foo")
+	a.NoError(err)
+	a.Equal("too old", releases[0].Deprecated)
+	a.Equal("", releases[1].Deprecated)
+
+	l, err = d.ListVersions(context.Background(), "@scope/bar")
+	a.NoError(err)
+	a.Equal([]string{"2.0.0"}, versionStrings(l))
+
+	_, err = d.ListVersions(context.Background(), "missing")
+	a.True(errors.Is(err, ErrNotFound))
+
+	_, err = d.ListVersions(context.Background(), "../etc")
+	a.Error(err)
+}
+
+func TestCacheCoalescing(t *testing.T) {
+	a := assert.New(t)
+
+	var hits int32
+	release := make(chan struct{})
+	s := newServer(&hits, release)
+	defer s.Close()
+
+	c := NewCache(&HTTP{BaseURL: s.URL}, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			l, err := c.ListVersions(context.Background(), "left-pad")
+			a.NoError(err)
+			a.Len(l, 4)
+		}()
+	}
+
+	for atomic.LoadInt32(&hits) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	a.Equal(int32(1), atomic.LoadInt32(&hits))
+
+	_, err := c.ListVersions(context.Background(), "left-pad")
+	a.NoError(err)
+	a.Equal(int32(1), atomic.LoadInt32(&hits))
+
+	c.Forget("left-pad")
+	_, err = c.ListVersions(context.Background(), "left-pad")
+	a.NoError(err)
+	a.Equal(int32(2), atomic.LoadInt32(&hits))
+
+	_, err = c.ListVersions(context.Background(), "broken")
+	a.Error(err)
+	_, err = c.ListVersions(context.Background(), "broken")
+	a.Error(err)
+	a.Equal(int32(4), atomic.LoadInt32(&hits))
+}
+
+func TestCacheTTL(t *testing.T) {
+	a := assert.New(t)
+
+	var hits int32
+	s := newServer(&hits, nil)
+	defer s.Close()
+
+	c := NewCache(&HTTP{BaseURL: s.URL}, time.Millisecond)
+
+	_, err := c.ListVersions(context.Background(), "left-pad")
+	a.NoError(err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = c.ListVersions(context.Background(), "left-pad")
+	a.NoError(err)
+	a.Equal(int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestCacheCancel(t *testing.T) {
+	a := assert.New(t)
+
+	var hits int32
+	release := make(chan struct{})
+	s := newServer(&hits, release)
+	defer s.Close()
+
+	c := NewCache(&HTTP{BaseURL: s.URL}, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	first := make(chan error)
+	go func() {
+		_, err := c.ListVersions(ctx, "left-pad")
+		first <- err
+	}()
+
+	for atomic.LoadInt32(&hits) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	second := make(chan error)
+	go func() {
+		l, err := c.ListVersions(context.Background(), "left-pad")
+		a.Len(l, 4)
+		second <- err
+	}()
+
+	cancel()
+	a.ErrorIs(<-first, context.Canceled)
+
+	close(release)
+	a.NoError(<-second)
+	a.Equal(int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestCacheTimeout(t *testing.T) {
+	a := assert.New(t)
+
+	var hits int32
+	release := make(chan struct{})
+	s := newServer(&hits, release)
+	defer s.Close()
+	defer close(release)
+
+	c := NewCache(&HTTP{BaseURL: s.URL}, 0)
+	c.Timeout = 10 * time.Millisecond
+
+	_, err := c.ListVersions(context.Background(), "left-pad")
+	a.ErrorIs(err, context.DeadlineExceeded)
+}
+
+type hungRegistry struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (r *hungRegistry) ListVersions(ctx context.Context, name string) (semver.List, error) {
+	return nil, errors.New("not implemented")
+}
+
+// Releases ignores ctx, like a Registry stuck in a call that can't be
+// interrupted.
+func (r *hungRegistry) Releases(ctx context.Context, name string) ([]Release, error) {
+	atomic.AddInt32(&r.calls, 1)
+	<-r.release
+
+	return nil, errors.New("released")
+}
+
+func TestCacheHung(t *testing.T) {
+	a := assert.New(t)
+
+	r := &hungRegistry{release: make(chan struct{})}
+	defer close(r.release)
+
+	a.Equal(DefaultTimeout, NewCache(r, 0).Timeout)
+
+	c := &Cache{Registry: r, Timeout: 20 * time.Millisecond}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := c.Releases(context.Background(), "x")
+			a.ErrorIs(err, context.DeadlineExceeded)
+		}()
+	}
+	wg.Wait()
+
+	a.Equal(int32(1), atomic.LoadInt32(&r.calls))
+
+	_, err := c.Releases(context.Background(), "x")
+	a.ErrorIs(err, context.DeadlineExceeded)
+	a.Equal(int32(2), atomic.LoadInt32(&r.calls), "a timed out lookup isn't cached")
+}
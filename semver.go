@@ -1,7 +1,181 @@
 package semver
 
+import (
+	"sort"
+	"strings"
+)
+
 type List []Version
 
 func (l List) Len() int           { return len(l) }
 func (l List) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
 func (l List) Less(i, j int) bool { return l[i].LessThan(l[j]) }
+
+func (l List) sorted() List {
+	r := make(List, len(l))
+	copy(r, l)
+
+	sort.Sort(r)
+
+	return r
+}
+
+// TieBreak decides the order of versions with equal precedence when sorting
+// with SortStableBy.
+type TieBreak int
+
+const (
+	// TieBreakPosition keeps versions of equal precedence in the order they
+	// were in before sorting.
+	TieBreakPosition TieBreak = iota
+	// TieBreakBuild orders versions of equal precedence by their build
+	// metadata as plain strings, so 1.0.0 comes before 1.0.0+10 and
+	// 1.0.0+10 before 1.0.0+9.
+	TieBreakBuild
+)
+
+func (l List) sortStable(tie TieBreak, desc bool) {
+	sort.SliceStable(l, func(i, j int) bool {
+		c := comparePrecedence(l[i], l[j])
+
+		if c == 0 && tie == TieBreakBuild {
+			c = strings.Compare(strings.Join(l[i].Build, "."), strings.Join(l[j].Build, "."))
+		}
+
+		if desc {
+			return c > 0
+		}
+
+		return c < 0
+	})
+}
+
+// SortStable sorts l in place by precedence alone, unlike sort.Sort(l), and
+// leaves versions that differ only in build metadata in their original order.
+func (l List) SortStable() {
+	l.sortStable(TieBreakPosition, false)
+}
+
+// SortStableBy is like SortStable, but orders versions of equal precedence
+// by tie.
+func (l List) SortStableBy(tie TieBreak) {
+	l.sortStable(tie, false)
+}
+
+// SortDescending sorts l in place from the highest precedence to the lowest.
+// Versions of equal precedence are ordered by tie, with TieBreakBuild putting
+// the lexically greatest build first.
+func (l List) SortDescending(tie TieBreak) {
+	l.sortStable(tie, true)
+}
+
+// Dedupe returns l without any version that has the same precedence as one
+// before it, so of 1.0.0+a and 1.0.0+b only the first is kept. Order is
+// otherwise preserved.
+func (l List) Dedupe() List {
+	idx := make([]int, len(l))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	sort.SliceStable(idx, func(a, b int) bool {
+		return comparePrecedence(l[idx[a]], l[idx[b]]) < 0
+	})
+
+	dupe := make([]bool, len(l))
+	for i := 1; i < len(idx); i++ {
+		if comparePrecedence(l[idx[i-1]], l[idx[i]]) == 0 {
+			dupe[idx[i]] = true
+		}
+	}
+
+	r := make(List, 0, len(l))
+	for i, v := range l {
+		if !dupe[i] {
+			r = append(r, v)
+		}
+	}
+
+	return r
+}
+
+func (l List) Filter(fn func(Version) bool) List {
+	r := make(List, 0, len(l))
+
+	for _, v := range l {
+		if fn(v) {
+			r = append(r, v)
+		}
+	}
+
+	return r
+}
+
+// Stable returns the versions in l that aren't prereleases.
+func (l List) Stable() List {
+	return l.Filter(func(v Version) bool { return len(v.Prerelease) == 0 })
+}
+
+func (l List) group(key func(a, b Version) bool) []List {
+	s := l.sorted()
+
+	var r []List
+
+	for i, v := range s {
+		if i == 0 || !key(s[i-1], v) {
+			r = append(r, nil)
+		}
+
+		r[len(r)-1] = append(r[len(r)-1], v)
+	}
+
+	return r
+}
+
+func sameMajor(a, b Version) bool { return a.Major == b.Major }
+func sameMinor(a, b Version) bool { return a.Major == b.Major && a.Minor == b.Minor }
+
+// GroupByMajor splits l into one sorted List per major version, in
+// ascending order.
+func (l List) GroupByMajor() []List {
+	return l.group(sameMajor)
+}
+
+// GroupByMinor splits l into one sorted List per major.minor line, in
+// ascending order.
+func (l List) GroupByMinor() []List {
+	return l.group(sameMinor)
+}
+
+func latest(groups []List) List {
+	r := make(List, len(groups))
+
+	for i, g := range groups {
+		r[i] = g[len(g)-1]
+	}
+
+	return r
+}
+
+// LatestPerMajor returns the highest version of each major version in l, in
+// ascending order.
+func (l List) LatestPerMajor() List {
+	return latest(l.GroupByMajor())
+}
+
+// LatestPerMinor returns the highest version of each major.minor line in l,
+// which is the latest patch release of the line, in ascending order.
+func (l List) LatestPerMinor() List {
+	return latest(l.GroupByMinor())
+}
+
+// SearchVersion looks for a version with the same precedence as v in l,
+// which must be sorted. It returns the index of the first such version, or
+// the index v would be inserted at and false if there isn't one.
+func (l List) SearchVersion(v Version) (int, bool) {
+	i := sort.Search(len(l), func(i int) bool {
+		return comparePrecedence(l[i], v) >= 0
+	})
+
+	return i, i < len(l) && comparePrecedence(l[i], v) == 0
+}
@@ -1,7 +1,13 @@
 package semver
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -245,6 +251,8 @@ func TestPositiveMatch(t *testing.T) {
 		{"^1.2.3-alpha", "1.2.3-pre"},
 		{"^1.2.0-alpha", "1.2.0-pre"},
 		{"^0.0.1-alpha", "0.0.1-beta"},
+		{"=1.2.3-beta", "1.2.3-beta"},
+		{">1.0.0-99999999999999999999", "1.0.0-100000000000000000000"},
 	}
 
 	for i, p := range pairs {
@@ -318,6 +326,11 @@ func TestNegativeMatch(t *testing.T) {
 		{"^1.2.3", "2.0.0-alpha"},
 		{"^1.2.3", "1.2.2"},
 		{"^1.2", "1.1.9"},
+		{"=1.2.3-beta", "1.2.3-alpha"},
+		{"1.2.3-beta", "1.2.3-alpha"},
+		{"=1.0.0-alpha.-1", "1.0.0-alpha.-01"},
+		{">=1.0.0-alpha.-1", "1.0.0-alpha.-01"},
+		{"<1.0.0-99999999999999999999", "1.0.0-100000000000000000000"},
 	}
 
 	for i, p := range pairs {
@@ -331,3 +344,1163 @@ func TestNegativeMatch(t *testing.T) {
 		a.False(r.SatisfiedBy(v), fmt.Sprintf("[%d] %s : %s", i, p[0], p[1]))
 	}
 }
+
+func TestCompiledMatcher(t *testing.T) {
+	a := assert.New(t)
+
+	ranges := []string{
+		"",
+		"*",
+		"1.0.0",
+		"=1.2.3-beta",
+		"1.0.0 - 2.0.0",
+		"1.2.3-pre+asdf - 2.4.3-pre+asdf",
+		">=0.2.3 || <0.0.1",
+		"1.2.x || 2.x",
+		"1.2.x || 1.3.x || 1.4.0 - 1.6.0",
+		"^1.2 ^1",
+		"~1.2.1 >=1.2.3",
+		">1.0.0 <1.0.0",
+		">=1.0.0 <=1.0.0",
+		"<1.0.0 || >1.0.0",
+		"<1.0.0 || >=1.0.0",
+		"<=1.0.0 || >1.0.0",
+		"^0.0.1-beta || ~0.0.1",
+		">=3 || 1 || 2",
+		"<2 || <1 || >=2.5",
+	}
+
+	versions := []string{
+		"0.0.0",
+		"0.0.1-alpha",
+		"0.0.1-beta",
+		"0.0.1-beta.2",
+		"0.0.1",
+		"0.0.2",
+		"0.2.3",
+		"0.9.9",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.0+build",
+		"1.0.1",
+		"1.2.0-pre",
+		"1.2.3-beta",
+		"1.2.3",
+		"1.3.0",
+		"1.5.7",
+		"1.6.0",
+		"1.6.1",
+		"2.0.0",
+		"2.4.3-alpha",
+		"2.4.3",
+		"2.5.0",
+		"3.0.0",
+		"10.0.0",
+	}
+
+	for _, rs := range ranges {
+		r, err := ParseRange(rs)
+		a.NoError(err, rs)
+
+		m := r.Compile()
+
+		for i := 1; i < len(m.intervals); i++ {
+			a.True(m.intervals[i-1].upper.below(m.intervals[i].lower.version), "%s: intervals overlap", rs)
+		}
+
+		for _, vs := range versions {
+			v, err := ParseVersion(vs)
+			a.NoError(err, vs)
+
+			a.Equal(r.SatisfiedBy(v), m.SatisfiedBy(v), "%s : %s", rs, vs)
+		}
+	}
+
+	r, err := ParseRange("1.2.x || 1.3.x || 1.4.0 - 1.6.0")
+	a.NoError(err)
+	a.Len(r.Compile().intervals, 1)
+
+	r, err = ParseRange(">1.0.0 <1.0.0")
+	a.NoError(err)
+	a.Len(r.Compile().intervals, 0)
+}
+
+func benchmarkRange(b *testing.B) (Range, List) {
+	r, err := ParseRange(">=0.2.3 <0.3.0 || ~1.2.1 >=1.2.3 || ^2.4 || 3.x || >=5.0.0-rc.1 <5.1.0")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var l List
+	for _, s := range []string{"0.1.0", "0.2.5", "1.2.4", "2.3.9", "2.9.1", "3.1.4", "4.0.0", "5.0.0-rc.2", "5.0.3", "6.0.0"} {
+		v, err := ParseVersion(s)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		l = append(l, v)
+	}
+
+	return r, l
+}
+
+func BenchmarkRangeSatisfiedBy(b *testing.B) {
+	r, l := benchmarkRange(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.SatisfiedBy(l[i%len(l)])
+	}
+}
+
+func BenchmarkMatcherSatisfiedBy(b *testing.B) {
+	r, l := benchmarkRange(b)
+	m := r.Compile()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		m.SatisfiedBy(l[i%len(l)])
+	}
+}
+
+func dumpVersion(v Version) string {
+	return fmt.Sprintf("%d.%d.%d%q%q", v.Major, v.Minor, v.Patch, v.Prerelease, v.Build)
+}
+
+func dumpRange(r Range) string {
+	if r == nil {
+		return "nil"
+	}
+
+	l := make([]string, len(r))
+	for i, s := range r {
+		c := make([]string, len(s))
+		for j, e := range s {
+			c[j] = string(e.Operator) + " " + dumpVersion(e.Version)
+		}
+		l[i] = "[" + strings.Join(c, ", ") + "]"
+	}
+
+	return strings.Join(l, " ")
+}
+
+func dumpError(err error) string {
+	if err == nil {
+		return `""`
+	}
+
+	return strconv.Quote(err.Error())
+}
+
+// testdata/versions.txt and testdata/ranges.txt were generated by the
+// go-lexer based parser this package used to have. Each line is a quoted
+// input, the parsed result and the quoted error, separated by tabs. The
+// deliberate differences since then are that ParseRange now rejects numbers
+// that don't fit in an int64 instead of silently clamping them, understands
+// the != operator, and expands carets on all-zero versions the way
+// node-semver does (^0 is <1.0.0, ^0.0 is <0.1.0 and ^x is anything).
+func readCorpus(t testing.TB, name string) [][3]string {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var l [][3]string
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		parts := strings.Split(s.Text(), "\t")
+		if len(parts) != 3 {
+			t.Fatalf("%s: malformed line %q", name, s.Text())
+		}
+
+		input, err := strconv.Unquote(parts[0])
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		l = append(l, [3]string{input, parts[1], parts[2]})
+	}
+
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return l
+}
+
+func TestVersionParserCorpus(t *testing.T) {
+	a := assert.New(t)
+
+	for _, e := range readCorpus(t, "testdata/versions.txt") {
+		v, err := ParseVersion(e[0])
+		a.Equal(e[1], dumpVersion(v), e[0])
+		a.Equal(e[2], dumpError(err), e[0])
+
+		v, err = ParseVersionBytes([]byte(e[0]))
+		a.Equal(e[1], dumpVersion(v), e[0])
+		a.Equal(e[2], dumpError(err), e[0])
+	}
+}
+
+func TestRangeParserCorpus(t *testing.T) {
+	a := assert.New(t)
+
+	for _, e := range readCorpus(t, "testdata/ranges.txt") {
+		r, err := ParseRange(e[0])
+		a.Equal(e[1], dumpRange(r), e[0])
+		a.Equal(e[2], dumpError(err), e[0])
+	}
+}
+
+func checkVersionProperties(t *testing.T, s string) {
+	v1, err1 := ParseVersion(s)
+	v2, err2 := ParseVersionBytes([]byte(s))
+
+	if dumpVersion(v1) != dumpVersion(v2) || dumpError(err1) != dumpError(err2) {
+		t.Fatalf("%q: ParseVersion gave %s %s, ParseVersionBytes gave %s %s", s, dumpVersion(v1), dumpError(err1), dumpVersion(v2), dumpError(err2))
+	}
+
+	if err1 != nil {
+		return
+	}
+
+	v3, err := ParseVersion(v1.String())
+	if err != nil {
+		t.Fatalf("%q: %s doesn't parse: %s", s, v1, err)
+	}
+
+	if v1.Compare(v3) != 0 || dumpVersion(v1) != dumpVersion(v3) {
+		t.Fatalf("%q: %s parsed as %s", s, v1, v3)
+	}
+}
+
+func dumpMatcher(m *Matcher) string {
+	b := func(b bound) string {
+		if b.unbounded {
+			return "inf"
+		}
+
+		return fmt.Sprintf("%s/%v", b.version, b.inclusive)
+	}
+
+	l := make([]string, len(m.intervals))
+	for i, e := range m.intervals {
+		l[i] = b(e.lower) + ".." + b(e.upper)
+	}
+
+	return strings.Join(l, " ")
+}
+
+func checkRangeProperties(t *testing.T, s string) {
+	r1, err := ParseRange(s)
+	if err != nil {
+		return
+	}
+
+	r2, err := ParseRange(r1.String())
+	if err != nil {
+		t.Fatalf("%q: %q doesn't parse: %s", s, r1.String(), err)
+	}
+
+	if r1.String() != r2.String() {
+		t.Fatalf("%q: %q parsed as %q", s, r1.String(), r2.String())
+	}
+
+	if dumpMatcher(r1.Compile()) != dumpMatcher(r2.Compile()) {
+		t.Fatalf("%q: %q isn't equivalent to %q", s, r1.String(), r2.String())
+	}
+}
+
+func checkCompareProperties(t *testing.T, l ...Version) {
+	sign := func(n int) int {
+		switch {
+		case n < 0:
+			return -1
+		case n > 0:
+			return 1
+		}
+
+		return 0
+	}
+
+	for _, a := range l {
+		if a.Compare(a) != 0 {
+			t.Fatalf("%s != %s", a, a)
+		}
+
+		for _, b := range l {
+			if sign(a.Compare(b)) != -sign(b.Compare(a)) {
+				t.Fatalf("compare(%s, %s) = %d but compare(%s, %s) = %d", a, b, a.Compare(b), b, a, b.Compare(a))
+			}
+
+			if a.LessThan(b) != (a.Compare(b) < 0) || a.GreaterThan(b) != (a.Compare(b) > 0) {
+				t.Fatalf("LessThan/GreaterThan disagree with Compare for %s, %s", a, b)
+			}
+
+			for _, c := range l {
+				if a.Compare(b) <= 0 && b.Compare(c) <= 0 && a.Compare(c) > 0 {
+					t.Fatalf("%s <= %s <= %s but %s > %s", a, b, c, a, c)
+				}
+			}
+		}
+	}
+}
+
+func TestVersionProperties(t *testing.T) {
+	for _, e := range readCorpus(t, "testdata/versions.txt") {
+		checkVersionProperties(t, e[0])
+	}
+}
+
+func TestRangeProperties(t *testing.T) {
+	for _, e := range readCorpus(t, "testdata/ranges.txt") {
+		checkRangeProperties(t, e[0])
+	}
+}
+
+func TestCompareProperties(t *testing.T) {
+	var l []Version
+
+	for _, s := range []string{
+		"0.0.0", "1.0.0", "1.0.0+x", "1.0.0+y", "1.0.0+x.1", "1.0.0+1", "1.0.0+01", "1.0.0-1", "1.0.0-2", "1.0.0-10",
+		"1.0.0-1a", "1.0.0-a", "1.0.0-a.1", "1.0.0-a.b", "1.0.0-a.1+x", "1.0.0-01", "1.0.0-A", "1.0.0--", "2.0.0", "1.10.0",
+	} {
+		v, err := ParseVersion(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l = append(l, v)
+	}
+
+	checkCompareProperties(t, l...)
+}
+
+func TestParserErrors(t *testing.T) {
+	a := assert.New(t)
+
+	for _, s := range []string{"- 1.2.3", " - 1.2.3", "1.2.3 -", "1.2.3 - ", "|| 1.2.3", "^9223372036854775807", "~1.9223372036854775807", "1.0.0 - 99999999999999999999"} {
+		a.NotPanics(func() {
+			_, err := ParseRange(s)
+			a.Error(err, s)
+		}, s)
+	}
+}
+
+func FuzzParseVersion(f *testing.F) {
+	for _, e := range readCorpus(f, "testdata/versions.txt") {
+		f.Add(e[0])
+	}
+
+	f.Fuzz(checkVersionProperties)
+}
+
+func FuzzParseRange(f *testing.F) {
+	for _, e := range readCorpus(f, "testdata/ranges.txt") {
+		f.Add(e[0])
+	}
+
+	f.Fuzz(checkRangeProperties)
+}
+
+func FuzzCompare(f *testing.F) {
+	f.Add("1.0.0", "1.0.0+x", "1.0.0-a.1")
+	f.Add("1.0.0-2", "1.0.0-10", "1.0.0-1a")
+
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		var l []Version
+
+		for _, s := range []string{a, b, c} {
+			if v, err := ParseVersion(s); err == nil {
+				l = append(l, v)
+			}
+		}
+
+		checkCompareProperties(t, l...)
+	})
+}
+
+func TestParseVersionAllocs(t *testing.T) {
+	a := assert.New(t)
+
+	b := []byte("v1.22.333")
+
+	a.Equal(0.0, testing.AllocsPerRun(100, func() { ParseVersion("1.22.333") }))
+	a.Equal(0.0, testing.AllocsPerRun(100, func() { ParseVersionBytes(b) }))
+}
+
+func BenchmarkParseVersion(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		ParseVersion("1.22.333")
+	}
+}
+
+func BenchmarkParseVersionPrerelease(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		ParseVersion("1.22.333-beta.4+build.5")
+	}
+}
+
+func BenchmarkParseVersionBytes(b *testing.B) {
+	v := []byte("1.22.333")
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		ParseVersionBytes(v)
+	}
+}
+
+func BenchmarkParseRange(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		ParseRange(">=0.2.3 <0.3.0 || ~1.2.1 >=1.2.3 || ^2.4 || 3.x")
+	}
+}
+
+func TestNotEqual(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		r, v string
+		ok   bool
+	}{
+		{"!=2.3.1", "2.3.1", false},
+		{"!=2.3.1", "2.3.1+build", false},
+		{"!=2.3.1", "2.3.2", true},
+		{"!=2.3.1", "2.3.1-beta", true},
+		{"!= 2.3.1", "2.3.0", true},
+		{"^2.3 !=2.3.1", "2.3.1", false},
+		{"^2.3 !=2.3.1", "2.3.2", true},
+		{"^2.3 !=2.3.1", "3.0.0", false},
+		{"^2.3 !=2.3.1 !=2.3.2 || 2.3.2", "2.3.2", true},
+	}
+
+	for _, p := range pairs {
+		r, err := ParseRange(p.r)
+		a.NoError(err, p.r)
+
+		v, err := ParseVersion(p.v)
+		a.NoError(err, p.v)
+
+		a.Equal(p.ok, r.SatisfiedBy(v), "%s : %s", p.r, p.v)
+		a.Equal(p.ok, r.Compile().SatisfiedBy(v), "%s : %s (compiled)", p.r, p.v)
+	}
+
+	r, err := ParseRange("!=  v2.3.1")
+	a.NoError(err)
+	a.Equal("!=2.3.1", r.String())
+
+	for _, s := range []string{"!=2.3", "!=2", "!=*", "!=2.x.1", "!2.3.1", "=!2.3.1"} {
+		_, err := ParseRange(s)
+		a.Error(err, s)
+	}
+}
+
+func TestComplement(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := [][2]string{
+		{"", "<0.0.0-0"},
+		{"*", "<0.0.0"},
+		{"<0.0.0-0", ""},
+		{">2.0.0 <1.0.0", ""},
+		{"^1", "<1.0.0 || >=2.0.0"},
+		{"^1.2.3", "<1.2.3 || >=2.0.0"},
+		{"2.3.1", "<2.3.1 || >2.3.1"},
+		{"!=2.3.1", "=2.3.1"},
+		{">=1.0.0 !=1.5.0", "<1.0.0 || =1.5.0"},
+		{"1.x || 3.x", "<1.0.0 || >=2.0.0 <3.0.0 || >=4.0.0"},
+		{"1.x || 2.x", "<1.0.0 || >=3.0.0"},
+		{"<=1.0.0 || >2.0.0", ">1.0.0 <=2.0.0"},
+		{"1.0.0 - 2.0.0", "<1.0.0 || >2.0.0"},
+	}
+
+	versions := []string{
+		"0.0.0-0", "0.0.0", "0.5.0", "1.0.0-rc.1", "1.0.0", "1.2.3", "1.5.0", "1.5.1", "2.0.0-alpha",
+		"2.0.0", "2.3.0", "2.3.1", "2.3.2", "3.0.0", "3.9.9", "4.0.0", "10.0.0",
+	}
+
+	for _, p := range pairs {
+		r, err := ParseRange(p[0])
+		a.NoError(err, p[0])
+
+		c := r.Complement()
+		a.Equal(p[1], c.String(), p[0])
+
+		c2, err := ParseRange(c.String())
+		a.NoError(err, c.String())
+		a.Equal(dumpMatcher(c.Compile()), dumpMatcher(c2.Compile()), p[0])
+		a.Equal(dumpMatcher(r.Compile()), dumpMatcher(c.Complement().Compile()), p[0])
+
+		for _, s := range versions {
+			v, err := ParseVersion(s)
+			a.NoError(err, s)
+
+			a.NotEqual(r.SatisfiedBy(v), c.SatisfiedBy(v), "%s : %s", p[0], s)
+		}
+	}
+}
+
+var combinatorVersions = []string{
+	"0.0.0-0", "0.0.0", "0.5.0", "1.0.0-rc.1", "1.0.0", "1.2.3", "1.5.0", "1.9.9", "2.0.0-alpha",
+	"2.0.0", "2.3.1", "2.5.0", "3.0.0", "3.9.9", "4.0.0", "10.0.0",
+}
+
+func checkCombinator(a *assert.Assertions, name string, op func(Range, Range) Range, pred func(bool, bool) bool, pairs [][3]string) {
+	for _, p := range pairs {
+		r1, err := ParseRange(p[0])
+		a.NoError(err, p[0])
+
+		r2, err := ParseRange(p[1])
+		a.NoError(err, p[1])
+
+		r := op(r1, r2)
+		a.Equal(p[2], r.String(), "%s(%q, %q)", name, p[0], p[1])
+
+		r3, err := ParseRange(r.String())
+		a.NoError(err, r.String())
+		a.True(r.Equal(r3), r.String())
+
+		for _, s := range combinatorVersions {
+			v, err := ParseVersion(s)
+			a.NoError(err, s)
+
+			a.Equal(pred(r1.SatisfiedBy(v), r2.SatisfiedBy(v)), r.SatisfiedBy(v), "%s(%q, %q) : %s", name, p[0], p[1], s)
+		}
+	}
+}
+
+func TestUnion(t *testing.T) {
+	checkCombinator(assert.New(t), "Union", Range.Union, func(a, b bool) bool { return a || b }, [][3]string{
+		{"", "^1", ""},
+		{"^1", "", ""},
+		{"^1", "^2", ">=1.0.0 <3.0.0"},
+		{"^2", "^1", ">=1.0.0 <3.0.0"},
+		{"^1", "^3", ">=1.0.0 <2.0.0 || >=3.0.0 <4.0.0"},
+		{"^1.2 || ^1.5", "1.0.0", "=1.0.0 || >=1.2.0 <2.0.0"},
+		{"<1 || >=2", "1.x", ""},
+		{">1.0.0 <2.0.0", "1.0.0", ">=1.0.0 <2.0.0"},
+		{">2 <1", ">3 <1", "<0.0.0-0"},
+	})
+}
+
+func TestIntersect(t *testing.T) {
+	checkCombinator(assert.New(t), "Intersect", Range.Intersect, func(a, b bool) bool { return a && b }, [][3]string{
+		{"", "^1", ">=1.0.0 <2.0.0"},
+		{"^1", "^2", "<0.0.0-0"},
+		{"^1.2", "~1.5", ">=1.5.0 <1.6.0"},
+		{"1.x || 3.x", ">=1.5.0 <3.5.0", ">=1.5.0 <2.0.0 || >=3.0.0 <3.5.0"},
+		{"<=2.0.0", ">=2.0.0", "=2.0.0"},
+	})
+}
+
+func TestDifference(t *testing.T) {
+	checkCombinator(assert.New(t), "Difference", Range.Difference, func(a, b bool) bool { return a && !b }, [][3]string{
+		{"", "", "<0.0.0-0"},
+		{"", "^1", "<1.0.0 || >=2.0.0"},
+		{"^1", "", "<0.0.0-0"},
+		{"^2", "2.3.1", ">=2.0.0 <2.3.1 || >2.3.1 <3.0.0"},
+		{"1.x || 2.x", "^1", ">=2.0.0 <3.0.0"},
+		{"^1", "^3", ">=1.0.0 <2.0.0"},
+	})
+}
+
+func TestEqual(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		a, b string
+		ok   bool
+	}{
+		{"", "", true},
+		{"", "*", false},
+		{"", ">=0.0.0-0", true},
+		{"^1", "1.x", true},
+		{"^1", ">=1.0.0 <2.0.0", true},
+		{"^1", "~1", true},
+		{"^1.2.3", ">=1.2.3 <2.0.0-0", false},
+		{"1.x || 2.x", ">=1.0.0 <3.0.0", true},
+		{"1.x || 3.x", ">=1.0.0 <4.0.0", false},
+		{"1.0.0", "=1.0.0+build", true},
+		{">2 <1", "<0.0.0-0", true},
+		{"^1 !=1.5.0", "^1 !=1.5.0 !=1.5.0", true},
+	}
+
+	for _, p := range pairs {
+		r1, err := ParseRange(p.a)
+		a.NoError(err, p.a)
+
+		r2, err := ParseRange(p.b)
+		a.NoError(err, p.b)
+
+		a.Equal(p.ok, r1.Equal(r2), "%q == %q", p.a, p.b)
+		a.Equal(p.ok, r2.Equal(r1), "%q == %q", p.b, p.a)
+	}
+
+	a.Equal("<0.0.0-0", Range{}.String())
+	a.False(Range{}.SatisfiedBy(Version{}))
+	a.True(Range{nil}.SatisfiedBy(Version{}))
+	a.True(Range{}.Equal(Range{Set{{Operator: OperatorLT, Version: Version{Prerelease: []string{"0"}}}}}))
+}
+
+func TestMinVersion(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := [][2]string{
+		{"", "0.0.0"},
+		{"*", "0.0.0"},
+		{"<0.0.0", "0.0.0-0"},
+		{"1.0.0", "1.0.0"},
+		{"1.0.0+build", "1.0.0"},
+		{"1.0", "1.0.0"},
+		{"^1.2.3-beta.2", "1.2.3-beta.2"},
+		{">1.0.0", "1.0.1"},
+		{">1.0.0-beta", "1.0.0-beta.0"},
+		{">1.0.0 <1.0.1", "1.0.1-0"},
+		{"<2.0.0 >1.0.0 || >=0.5.0 <0.6.0", "0.5.0"},
+		{">=1.0.0 !=1.0.0", "1.0.1"},
+		{"^2 || ^1", "1.0.0"},
+		{">=2.0.0 <1.0.0", ""},
+	}
+
+	for _, p := range pairs {
+		r, err := ParseRange(p[0])
+		a.NoError(err, p[0])
+
+		v, ok := r.MinVersion()
+		a.Equal(p[1] != "", ok, p[0])
+
+		if ok {
+			a.Equal(p[1], v.String(), p[0])
+			a.True(r.SatisfiedBy(v), p[0])
+		}
+	}
+}
+
+func TestSetBounds(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		r            string
+		lower, upper string
+	}{
+		{"^1.2.0", ">=1.2.0", "<2.0.0"},
+		{"~1.2", ">=1.2.0", "<1.3.0"},
+		{">1.0.0", ">1.0.0", "inf"},
+		{"<=2.0.0", "inf", "<=2.0.0"},
+		{"", "inf", "inf"},
+		{"1.2.3", ">=1.2.3", "<=1.2.3"},
+		{">=1.0.0 <=1.5.0 !=1.5.0", ">=1.0.0", "<1.5.0"},
+		{">=1.0.0 <2.0.0 !=1.5.0", ">=1.0.0", "<2.0.0"},
+		{">=2.0.0 <1.0.0", "", ""},
+	}
+
+	str := func(b Bound, lower bool) string {
+		switch {
+		case b.Unbounded:
+			return "inf"
+		case lower && b.Inclusive:
+			return ">=" + b.Version.String()
+		case lower:
+			return ">" + b.Version.String()
+		case b.Inclusive:
+			return "<=" + b.Version.String()
+		}
+
+		return "<" + b.Version.String()
+	}
+
+	for _, p := range pairs {
+		r, err := ParseRange(p.r)
+		a.NoError(err, p.r)
+
+		lower, upper, ok := r[0].Bounds()
+		a.Equal(p.lower != "", ok, p.r)
+
+		if ok {
+			a.Equal(p.lower, str(lower, true), p.r)
+			a.Equal(p.upper, str(upper, false), p.r)
+		}
+
+		l, _ := r[0].LowerBound()
+		a.Equal(lower, l, p.r)
+		u, _ := r[0].UpperBound()
+		a.Equal(upper, u, p.r)
+	}
+}
+
+func TestRangePredicates(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		r                       string
+		empty, unbounded, exact bool
+	}{
+		{"", false, true, false},
+		{"*", false, true, false},
+		{">=1.0.0", false, true, false},
+		{"^1 || >=3", false, true, false},
+		{"^1", false, false, false},
+		{"^1 || ^3", false, false, false},
+		{"1.2.3", false, false, true},
+		{"=1.2.3", false, false, true},
+		{">=1.2.3 <=1.2.3", false, false, true},
+		{"1.2.3 || 1.2.3+build", false, false, true},
+		{"1.2.3 || 1.2.4", false, false, false},
+		{">2 <1", true, false, false},
+		{"<0.0.0-0", true, false, false},
+		{"1.2.3 !=1.2.3", true, false, false},
+	}
+
+	for _, p := range pairs {
+		r, err := ParseRange(p.r)
+		a.NoError(err, p.r)
+
+		a.Equal(p.empty, r.IsEmpty(), "%q IsEmpty", p.r)
+		a.Equal(p.unbounded, r.IsUnbounded(), "%q IsUnbounded", p.r)
+		a.Equal(p.exact, r.IsExact(), "%q IsExact", p.r)
+	}
+
+	a.True(Range{}.IsEmpty())
+}
+
+func TestOutside(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		r, v   string
+		gt, lt bool
+	}{
+		{"~1.2", "1.3.0", true, false},
+		{"~1.2", "1.2.0-rc.1", false, true},
+		{"1.0.0 - 2.0.0", "2.0.1", true, false},
+		{"1.0.0 - 2.0.0", "0.9.9", false, true},
+		{"1.0.0 - 2.0.0", "1.5.0", false, false},
+		{"1.0.0", "1.0.1-beta1", true, false},
+		{"1.0.0", "0.9.9", false, true},
+		{"<=2.0.0", "3.0.0", true, false},
+		{"<=2.0.0", "2.0.0", false, false},
+		{"<2.0.0", "2.0.0", true, false},
+		{">=1.0.0", "0.0.1", false, true},
+		{">=1.0.0", "100.0.0", false, false},
+		{"^1.2.3", "2.0.0-alpha", false, false},
+		{"^1.2.3", "2.0.0", true, false},
+		{"^1.2.3", "1.2.3-beta", false, true},
+		{"0.1.20 || 1.2.4", "1.2.5", true, false},
+		{"0.1.20 || 1.2.4", "1.2.3", false, false},
+		{"0.1.20 || 1.2.4", "0.1.19", false, true},
+		{"1.x || 3.x", "2.0.0", false, false},
+		{"1.x || 3.x", "4.0.0", true, false},
+		{"1.x || 3.x", "0.9.0", false, true},
+		{"", "1.0.0", false, false},
+		{"*", "0.0.0-alpha", false, true},
+		{">2 <1", "1.0.0", false, false},
+	}
+
+	for _, p := range pairs {
+		r, err := ParseRange(p.r)
+		a.NoError(err, p.r)
+
+		v, err := ParseVersion(p.v)
+		a.NoError(err, p.v)
+
+		a.Equal(p.gt, r.Outside(v, OperatorGT), "%s > %q", p.v, p.r)
+		a.Equal(p.lt, r.Outside(v, OperatorLT), "%s < %q", p.v, p.r)
+		a.Equal(p.gt, v.GreaterThanRange(r), "%s > %q", p.v, p.r)
+		a.Equal(p.lt, v.LessThanRange(r), "%s < %q", p.v, p.r)
+		a.False(r.Outside(v, OperatorEQ))
+
+		if p.gt || p.lt {
+			a.False(r.SatisfiedBy(v), "%s : %q", p.v, p.r)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := [][3]string{
+		{"1.2.3", "0.2.3", "major"},
+		{"0.2.3", "1.2.3", "major"},
+		{"1.4.5", "0.2.3", "major"},
+		{"1.2.3", "2.0.0-pre", "premajor"},
+		{"1.2.3", "1.3.3", "minor"},
+		{"1.0.1", "1.1.0-pre", "preminor"},
+		{"1.2.3", "1.2.4", "patch"},
+		{"1.2.3", "1.2.4-pre", "prepatch"},
+		{"0.0.1", "0.0.1-pre", "patch"},
+		{"0.0.1", "0.0.1-pre-2", "patch"},
+		{"1.1.0", "1.1.0-pre", "minor"},
+		{"1.1.0-pre-1", "1.1.0-pre-2", "prerelease"},
+		{"1.0.0", "1.0.0", ""},
+		{"1.0.0-1", "1.0.0-1", ""},
+		{"1.0.0+a", "1.0.0+a", ""},
+		{"1.0.0+a", "1.0.0+b", "build"},
+		{"1.0.0", "1.0.0+b", "build"},
+		{"1.0.0-1+a", "1.0.0-1", "build"},
+		{"0.0.2-1", "0.0.2", "patch"},
+		{"0.0.2-1", "0.0.3", "patch"},
+		{"0.0.2-1", "0.1.0", "minor"},
+		{"0.0.2-1", "1.0.0", "major"},
+		{"0.1.0-1", "0.1.0", "minor"},
+		{"1.0.0-1", "1.0.0", "major"},
+		{"1.0.0-1", "1.1.1", "major"},
+		{"1.0.0-1", "2.1.1", "major"},
+		{"1.0.1-1", "1.0.1", "patch"},
+		{"0.0.0-1", "0.0.0", "major"},
+		{"1.0.0-1", "2.0.0", "major"},
+		{"1.0.0-1", "2.0.0-1", "premajor"},
+		{"1.0.0-1", "1.1.0-1", "preminor"},
+		{"1.0.0-1", "1.0.1-1", "prepatch"},
+		{"1.7.2-1", "1.8.1", "minor"},
+		{"1.1.1-pre", "2.1.1-pre", "premajor"},
+		{"1.1.1-pre", "2.1.1", "major"},
+		{"1.2.3-1", "1.2.3", "patch"},
+		{"1.4.0-1", "2.3.5", "major"},
+		{"1.6.1-5", "1.7.2", "minor"},
+		{"2.0.0-1", "2.1.1", "major"},
+	}
+
+	for _, p := range pairs {
+		v1, err := ParseVersion(p[0])
+		a.NoError(err, p[0])
+
+		v2, err := ParseVersion(p[1])
+		a.NoError(err, p[1])
+
+		a.Equal(ReleaseType(p[2]), Diff(v1, v2), "%s -> %s", p[0], p[1])
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"1.2.3", "1.2.4", true},
+		{"1.2.3", "1.9.0", true},
+		{"1.2.3", "2.0.0", false},
+		{"1.2.3", "2.0.0-rc.1", false},
+		{"1.2.3", "1.3.0-beta", true},
+		{"1.2.3", "1.2.2", false},
+		{"0.2.3", "0.2.9", true},
+		{"0.2.3", "0.3.0", false},
+		{"0.2.3", "0.3.0-alpha", false},
+		{"0.0.3", "0.0.3", true},
+		{"0.0.3", "0.0.4", false},
+		{"0.0.0", "0.0.0", true},
+		{"0.0.0", "0.0.1", false},
+		{"1.2.3-beta.1", "1.2.3-beta.2", true},
+		{"1.2.3-beta.1", "1.2.3", true},
+		{"1.2.3", "1.2.3+build", true},
+	}
+
+	for _, p := range pairs {
+		from, err := ParseVersion(p.from)
+		a.NoError(err, p.from)
+
+		to, err := ParseVersion(p.to)
+		a.NoError(err, p.to)
+
+		a.Equal(p.ok, Compatible(from, to), "%s -> %s", p.from, p.to)
+
+		// Ranges let prereleases through, so only releases are expected to
+		// agree with the caret range.
+		if len(to.Prerelease) == 0 {
+			r, err := ParseRange("^" + p.from)
+			a.NoError(err)
+			a.Equal(r.SatisfiedBy(to), Compatible(from, to), "%s -> %s", p.from, p.to)
+		}
+	}
+}
+
+func parseList(a *assert.Assertions, l ...string) List {
+	r := make(List, len(l))
+
+	for i, s := range l {
+		v, err := ParseVersion(s)
+		a.NoError(err, s)
+
+		r[i] = v
+	}
+
+	return r
+}
+
+func listStrings(l List) []string {
+	r := make([]string, len(l))
+
+	for i, v := range l {
+		r[i] = v.String()
+	}
+
+	return r
+}
+
+func TestListUtilities(t *testing.T) {
+	a := assert.New(t)
+
+	l := parseList(a, "2.1.0", "1.0.0", "1.2.3", "2.0.0-rc.1", "1.2.10", "1.0.0+b", "1.2.3", "3.0.0-beta", "1.10.0", "01.2.3", "2.1.0", "1.2.3-alpha")
+	orig := listStrings(l)
+
+	a.Equal([]string{"2.1.0", "1.0.0", "1.2.3", "2.0.0-rc.1", "1.2.10", "3.0.0-beta", "1.10.0", "1.2.3-alpha"}, listStrings(l.Dedupe()))
+	a.Equal([]string{"2.1.0", "1.0.0", "1.2.3", "1.2.10", "1.0.0+b", "1.2.3", "1.10.0", "1.2.3", "2.1.0"}, listStrings(l.Stable()))
+	a.Equal([]string{"1.2.3", "1.2.10", "1.2.3", "1.2.3", "1.2.3-alpha"}, listStrings(l.Filter(func(v Version) bool { return v.Major == 1 && v.Minor == 2 })))
+
+	groups := l.Dedupe().GroupByMajor()
+	a.Len(groups, 3)
+	a.Equal([]string{"1.0.0", "1.2.3-alpha", "1.2.3", "1.2.10", "1.10.0"}, listStrings(groups[0]))
+	a.Equal([]string{"2.0.0-rc.1", "2.1.0"}, listStrings(groups[1]))
+	a.Equal([]string{"3.0.0-beta"}, listStrings(groups[2]))
+
+	groups = l.Stable().Dedupe().GroupByMinor()
+	a.Len(groups, 4)
+	a.Equal([]string{"1.2.3", "1.2.10"}, listStrings(groups[1]))
+
+	a.Equal([]string{"1.10.0", "2.1.0", "3.0.0-beta"}, listStrings(l.LatestPerMajor()))
+	a.Equal([]string{"1.10.0", "2.1.0"}, listStrings(l.Stable().LatestPerMajor()))
+	a.Equal([]string{"1.0.0+b", "1.2.10", "1.10.0", "2.0.0-rc.1", "2.1.0", "3.0.0-beta"}, listStrings(l.LatestPerMinor()))
+
+	a.Equal(orig, listStrings(l))
+
+	a.Empty(List{}.Dedupe())
+	a.Empty(List{}.GroupByMajor())
+	a.Empty(List(nil).LatestPerMinor())
+}
+
+func TestSearchVersion(t *testing.T) {
+	a := assert.New(t)
+
+	l := parseList(a, "2.1.0", "1.0.0", "1.2.3", "1.2.3+b", "1.2.3-alpha", "1.10.0").sorted()
+	a.Equal([]string{"1.0.0", "1.2.3-alpha", "1.2.3", "1.2.3+b", "1.10.0", "2.1.0"}, listStrings(l))
+
+	pairs := []struct {
+		v     string
+		i     int
+		found bool
+	}{
+		{"0.1.0", 0, false},
+		{"1.0.0", 0, true},
+		{"1.0.0+x", 0, true},
+		{"1.2.3-alpha", 1, true},
+		{"1.2.3-beta", 2, false},
+		{"1.2.3", 2, true},
+		{"1.2.3+b", 2, true},
+		{"1.5.0", 4, false},
+		{"2.1.0", 5, true},
+		{"3.0.0", 6, false},
+	}
+
+	for _, p := range pairs {
+		v, err := ParseVersion(p.v)
+		a.NoError(err)
+
+		i, found := l.SearchVersion(v)
+		a.Equal(p.i, i, p.v)
+		a.Equal(p.found, found, p.v)
+	}
+}
+
+func TestSortStable(t *testing.T) {
+	a := assert.New(t)
+
+	in := []string{"1.0.0+b", "2.0.0", "1.0.0+a", "1.0.0-rc.1", "1.0.0", "1.0.0+10", "1.0.0+9", "0.9.0"}
+
+	pairs := []struct {
+		sort func(l List)
+		out  []string
+	}{
+		{List.SortStable, []string{"0.9.0", "1.0.0-rc.1", "1.0.0+b", "1.0.0+a", "1.0.0", "1.0.0+10", "1.0.0+9", "2.0.0"}},
+		{func(l List) { l.SortStableBy(TieBreakPosition) }, []string{"0.9.0", "1.0.0-rc.1", "1.0.0+b", "1.0.0+a", "1.0.0", "1.0.0+10", "1.0.0+9", "2.0.0"}},
+		{func(l List) { l.SortStableBy(TieBreakBuild) }, []string{"0.9.0", "1.0.0-rc.1", "1.0.0", "1.0.0+10", "1.0.0+9", "1.0.0+a", "1.0.0+b", "2.0.0"}},
+		{func(l List) { l.SortDescending(TieBreakPosition) }, []string{"2.0.0", "1.0.0+b", "1.0.0+a", "1.0.0", "1.0.0+10", "1.0.0+9", "1.0.0-rc.1", "0.9.0"}},
+		{func(l List) { l.SortDescending(TieBreakBuild) }, []string{"2.0.0", "1.0.0+b", "1.0.0+a", "1.0.0+9", "1.0.0+10", "1.0.0", "1.0.0-rc.1", "0.9.0"}},
+	}
+
+	for i, p := range pairs {
+		l := parseList(a, in...)
+		p.sort(l)
+
+		a.Equal(p.out, listStrings(l), "[%d]", i)
+	}
+}
+
+func TestReadVersions(t *testing.T) {
+	a := assert.New(t)
+
+	seq, errs := ReadVersions(strings.NewReader("1.2.3\n\n  v2.0.0-rc.1  \nlatest\n1.0.0\r\n1.2\n0.9.0+build.1\n"))
+
+	var l []string
+	for v := range seq {
+		l = append(l, v.String())
+	}
+
+	a.Equal([]string{"1.2.3", "2.0.0-rc.1", "1.0.0", "0.9.0+build.1"}, l)
+
+	err := errs()
+	a.EqualError(err, "line 4: \"latest\": invalid major version\nline 6: \"1.2\": minor version should be followed by a period")
+	a.ErrorIs(err, errInvalidMajor)
+
+	var le *LineError
+	if a.ErrorAs(err, &le) {
+		a.Equal(4, le.Line)
+	}
+
+	seq, errs = ReadVersions(strings.NewReader("1.0.0\nbad\n2.0.0\n"))
+	for v := range seq {
+		a.Equal("1.0.0", v.String())
+		break
+	}
+	a.NoError(errs())
+
+	rd := strings.NewReader("1.0.0\nbad\n")
+	seq, errs = ReadVersions(rd)
+	for range seq {
+	}
+	rd.Seek(0, io.SeekStart)
+	for range seq {
+	}
+	a.EqualError(errs(), "line 2: \"bad\": invalid major version")
+}
+
+func TestSeqHelpers(t *testing.T) {
+	a := assert.New(t)
+
+	l := parseList(a, "1.2.3", "2.1.0-rc.1", "0.9.0", "1.10.0", "1.2.3+b", "1.9.9", "3.0.0")
+
+	v, ok := Max(l.Values())
+	a.True(ok)
+	a.Equal("3.0.0", v.String())
+
+	v, ok = Min(l.Values())
+	a.True(ok)
+	a.Equal("0.9.0", v.String())
+
+	_, ok = Max(List{}.Values())
+	a.False(ok)
+
+	pairs := []struct {
+		r, best string
+		matched []string
+	}{
+		{"^1.2.0", "1.10.0", []string{"1.2.3", "1.10.0", "1.2.3+b", "1.9.9"}},
+		{"<1.5.0 || >=3.0.0", "3.0.0", []string{"1.2.3", "0.9.0", "1.2.3+b", "3.0.0"}},
+		{"~1.2.0", "1.2.3+b", []string{"1.2.3", "1.2.3+b"}},
+		{">=4.0.0", "", nil},
+	}
+
+	for _, p := range pairs {
+		r, err := ParseRange(p.r)
+		a.NoError(err)
+
+		var matched []string
+		for v := range r.Filter(l.Values()) {
+			matched = append(matched, v.String())
+		}
+		a.Equal(p.matched, matched, p.r)
+
+		v, ok := r.BestMatchSeq(l.Values())
+		a.Equal(p.best != "", ok, p.r)
+		if ok {
+			a.Equal(p.best, v.String(), p.r)
+
+			w, _ := r.BestMatch(l.sorted())
+			a.Equal(w.String(), v.String(), p.r)
+		}
+	}
+}
+
+func TestVersionFlag(t *testing.T) {
+	a := assert.New(t)
+
+	var _ interface {
+		flag.Getter
+		Type() string
+	} = &VersionFlag{}
+
+	var f VersionFlag
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&f, "min-version", "")
+
+	a.NoError(fs.Parse([]string{"-min-version", "v1.2.3-rc.1"}))
+	a.Equal("1.2.3-rc.1", f.String())
+	a.Equal(Version{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"rc", "1"}}, f.Version)
+	a.Equal("version", f.Type())
+
+	a.EqualError(fs.Parse([]string{"-min-version", "1.2"}), `invalid value "1.2" for flag -min-version: minor version should be followed by a period`)
+	a.Equal("1.2.3-rc.1", f.String())
+}
+
+func TestRangeFlag(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		or      bool
+		def     string
+		args    []string
+		r       string
+		in, out []string
+	}{
+		{false, "", nil, "", nil, nil},
+		{false, ">=1.0.0", nil, ">=1.0.0", []string{"1.0.0"}, []string{"0.9.0"}},
+		{false, ">=1.0.0", []string{"^2"}, ">=2.0.0 <3.0.0", []string{"2.5.0"}, []string{"1.0.0"}},
+		{false, "", []string{"^2", ">=2.3.0 || <1.0.0", "!=2.4.0"}, ">=2.0.0 <3.0.0 >=2.3.0 !=2.4.0 || >=2.0.0 <3.0.0 <1.0.0 !=2.4.0", []string{"2.3.0", "2.9.0"}, []string{"0.5.0", "2.2.0", "2.4.0", "3.0.0"}},
+		{true, "", []string{"^2", "~1.2", "4.0.0"}, ">=2.0.0 <3.0.0 || >=1.2.0 <1.3.0 || 4.0.0", []string{"1.2.5", "2.1.0", "4.0.0"}, []string{"1.3.0", "3.0.0"}},
+	}
+
+	for i, p := range pairs {
+		f := RangeFlag{Or: p.or}
+		if p.def != "" {
+			a.NoError(f.Set(p.def))
+			f.set = false
+		}
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Var(&f, "constraint", "")
+
+		var args []string
+		for _, s := range p.args {
+			args = append(args, "-constraint", s)
+		}
+
+		a.NoError(fs.Parse(args), "[%d]", i)
+		a.Equal(p.r, f.String(), "[%d]", i)
+		a.Equal("range", f.Type())
+
+		for _, s := range p.in {
+			v, err := ParseVersion(s)
+			a.NoError(err)
+			a.True(f.Range.SatisfiedBy(v), "[%d] %s", i, s)
+		}
+
+		for _, s := range p.out {
+			v, err := ParseVersion(s)
+			a.NoError(err)
+			a.False(f.Range.SatisfiedBy(v), "[%d] %s", i, s)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&RangeFlag{}, "constraint", "")
+
+	a.EqualError(fs.Parse([]string{"-constraint", "^1 || !=2"}), `invalid value "^1 || !=2" for flag -constraint: != requires a complete version`)
+}
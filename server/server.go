@@ -0,0 +1,446 @@
+// Package server exposes version and range queries over HTTP, so that
+// programs not written in Go can share this package's semantics.
+//
+// Every endpoint takes a JSON object in a POST body and answers with a JSON
+// object. Failures are reported with a non-2xx status and a body like
+// {"error":{"code":"invalid_range","message":"...","field":"range"}}.
+//
+//	/parse           {"version": "v1.2.3-rc.1"} or {"range": "^1.2"}
+//	/compare         {"a": "1.2.3", "b": "1.10.0"}
+//	/satisfies       {"version": "1.2.3", "range": "^1.2"}
+//	/max-satisfying  {"versions": ["1.2.3", "1.4.0"], "range": "^1.2"}
+//	/sort            {"versions": ["1.4.0", "1.2.3"], "descending": false}
+//	/simplify        {"range": ">=1.0.0 <2.0.0 || ^1.5.0"}
+//
+// To mount a Handler below a prefix, wrap it in http.StripPrefix.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/deoxxa/semver"
+)
+
+const (
+	DefaultMaxBodyBytes = 1 << 20
+	DefaultMaxVersions  = 10000
+	DefaultMaxLength    = 256
+)
+
+// Handler serves the endpoints listed in the package documentation. Its
+// zero value is ready to use, with each zero limit taking its default.
+type Handler struct {
+	// MaxBodyBytes limits the size of a request body.
+	MaxBodyBytes int64
+	// MaxVersions limits how many versions /max-satisfying and /sort take.
+	MaxVersions int
+	// MaxLength limits the length of each version or range string.
+	MaxLength int
+}
+
+// Error is the structured form of every error the Handler returns. Field
+// names the part of the request at fault, if there is one.
+type Error struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%s: %s", e.Field, e.Message)
+	}
+
+	return e.Message
+}
+
+const (
+	CodeBadRequest       = "bad_request"
+	CodeInvalidVersion   = "invalid_version"
+	CodeInvalidRange     = "invalid_range"
+	CodeTooLarge         = "too_large"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+)
+
+type VersionInfo struct {
+	Version    string   `json:"version"`
+	Major      int64    `json:"major"`
+	Minor      int64    `json:"minor"`
+	Patch      int64    `json:"patch"`
+	Prerelease []string `json:"prerelease"`
+	Build      []string `json:"build"`
+}
+
+type ParseRequest struct {
+	Version *string `json:"version,omitempty"`
+	Range   *string `json:"range,omitempty"`
+}
+
+// ParseResponse has Version set when a version was parsed, and Range, the
+// range as this package reads it, when a range was.
+type ParseResponse struct {
+	Version *VersionInfo `json:"version,omitempty"`
+	Range   *string      `json:"range,omitempty"`
+}
+
+type CompareRequest struct {
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+// CompareResponse has Result -1, 0 or 1 as a is lower than, equal to or
+// higher than b, taking build metadata into account. Precedence is the
+// same but ignores build metadata, as ranges do.
+type CompareResponse struct {
+	Result     int `json:"result"`
+	Precedence int `json:"precedence"`
+}
+
+type SatisfiesRequest struct {
+	Version string `json:"version"`
+	Range   string `json:"range"`
+}
+
+type SatisfiesResponse struct {
+	Satisfies bool `json:"satisfies"`
+}
+
+type MaxSatisfyingRequest struct {
+	Versions []string `json:"versions"`
+	Range    string   `json:"range"`
+}
+
+// MaxSatisfyingResponse has a null Version if nothing satisfied the range.
+type MaxSatisfyingResponse struct {
+	Version *string `json:"version"`
+}
+
+type SortRequest struct {
+	Versions   []string `json:"versions"`
+	Descending bool     `json:"descending"`
+}
+
+// SortResponse holds the versions in canonical form, sorted stably by
+// precedence.
+type SortResponse struct {
+	Versions []string `json:"versions"`
+}
+
+type SimplifyRequest struct {
+	Range string `json:"range"`
+}
+
+type SimplifyResponse struct {
+	Range string `json:"range"`
+}
+
+func (h *Handler) maxBodyBytes() int64 {
+	if h.MaxBodyBytes > 0 {
+		return h.MaxBodyBytes
+	}
+
+	return DefaultMaxBodyBytes
+}
+
+func (h *Handler) maxVersions() int {
+	if h.MaxVersions > 0 {
+		return h.MaxVersions
+	}
+
+	return DefaultMaxVersions
+}
+
+func (h *Handler) maxLength() int {
+	if h.MaxLength > 0 {
+		return h.MaxLength
+	}
+
+	return DefaultMaxLength
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var fn func(*http.Request) (any, error)
+
+	switch r.URL.Path {
+	case "/parse":
+		fn = h.parse
+	case "/compare":
+		fn = h.compare
+	case "/satisfies":
+		fn = h.satisfies
+	case "/max-satisfying":
+		fn = h.maxSatisfying
+	case "/sort":
+		fn = h.sort
+	case "/simplify":
+		fn = h.simplify
+	default:
+		writeError(w, &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: "no such endpoint"})
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, &Error{Status: http.StatusMethodNotAllowed, Code: CodeMethodNotAllowed, Message: "only POST is allowed"})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes())
+
+	res, err := fn(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Status: http.StatusInternalServerError, Code: "internal", Message: err.Error()}
+	}
+
+	writeJSON(w, e.Status, struct {
+		Error *Error `json:"error"`
+	}{e})
+}
+
+func decode(r *http.Request, v any) error {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	if err := d.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &Error{Status: http.StatusRequestEntityTooLarge, Code: CodeTooLarge, Message: fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit)}
+		}
+
+		return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: err.Error()}
+	}
+
+	if d.More() {
+		return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: "junk data after request body"}
+	}
+
+	return nil
+}
+
+func (h *Handler) checkLength(s, field string) error {
+	if len(s) > h.maxLength() {
+		return &Error{Status: http.StatusRequestEntityTooLarge, Code: CodeTooLarge, Message: fmt.Sprintf("longer than %d bytes", h.maxLength()), Field: field}
+	}
+
+	return nil
+}
+
+func (h *Handler) parseVersion(s, field string) (semver.Version, error) {
+	if err := h.checkLength(s, field); err != nil {
+		return semver.Version{}, err
+	}
+
+	v, err := semver.ParseVersion(s)
+	if err != nil {
+		return v, &Error{Status: http.StatusUnprocessableEntity, Code: CodeInvalidVersion, Message: err.Error(), Field: field}
+	}
+
+	return v, nil
+}
+
+func (h *Handler) parseRange(s, field string) (semver.Range, error) {
+	if err := h.checkLength(s, field); err != nil {
+		return nil, err
+	}
+
+	r, err := semver.ParseRange(s)
+	if err != nil {
+		return nil, &Error{Status: http.StatusUnprocessableEntity, Code: CodeInvalidRange, Message: err.Error(), Field: field}
+	}
+
+	return r, nil
+}
+
+func (h *Handler) parseList(l []string, field string) (semver.List, error) {
+	if len(l) > h.maxVersions() {
+		return nil, &Error{Status: http.StatusRequestEntityTooLarge, Code: CodeTooLarge, Message: fmt.Sprintf("more than %d versions", h.maxVersions()), Field: field}
+	}
+
+	r := make(semver.List, len(l))
+
+	for i, s := range l {
+		v, err := h.parseVersion(s, fmt.Sprintf("%s[%d]", field, i))
+		if err != nil {
+			return nil, err
+		}
+
+		r[i] = v
+	}
+
+	return r, nil
+}
+
+func (h *Handler) parse(r *http.Request) (any, error) {
+	var req ParseRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	if (req.Version == nil) == (req.Range == nil) {
+		return nil, &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: "exactly one of version and range is required"}
+	}
+
+	if req.Range != nil {
+		rng, err := h.parseRange(*req.Range, "range")
+		if err != nil {
+			return nil, err
+		}
+
+		s := rng.String()
+
+		return ParseResponse{Range: &s}, nil
+	}
+
+	v, err := h.parseVersion(*req.Version, "version")
+	if err != nil {
+		return nil, err
+	}
+
+	info := VersionInfo{
+		Version:    v.String(),
+		Major:      v.Major,
+		Minor:      v.Minor,
+		Patch:      v.Patch,
+		Prerelease: v.Prerelease,
+		Build:      v.Build,
+	}
+
+	if info.Prerelease == nil {
+		info.Prerelease = []string{}
+	}
+
+	if info.Build == nil {
+		info.Build = []string{}
+	}
+
+	return ParseResponse{Version: &info}, nil
+}
+
+func (h *Handler) compare(r *http.Request) (any, error) {
+	var req CompareRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	a, err := h.parseVersion(req.A, "a")
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := h.parseVersion(req.B, "b")
+	if err != nil {
+		return nil, err
+	}
+
+	pa, pb := a, b
+	pa.Build, pb.Build = nil, nil
+
+	return CompareResponse{Result: a.Compare(b), Precedence: pa.Compare(pb)}, nil
+}
+
+func (h *Handler) satisfies(r *http.Request) (any, error) {
+	var req SatisfiesRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	v, err := h.parseVersion(req.Version, "version")
+	if err != nil {
+		return nil, err
+	}
+
+	rng, err := h.parseRange(req.Range, "range")
+	if err != nil {
+		return nil, err
+	}
+
+	return SatisfiesResponse{Satisfies: rng.SatisfiedBy(v)}, nil
+}
+
+func (h *Handler) maxSatisfying(r *http.Request) (any, error) {
+	var req MaxSatisfyingRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	l, err := h.parseList(req.Versions, "versions")
+	if err != nil {
+		return nil, err
+	}
+
+	rng, err := h.parseRange(req.Range, "range")
+	if err != nil {
+		return nil, err
+	}
+
+	var res MaxSatisfyingResponse
+
+	if v, ok := rng.BestMatchSeq(l.Values()); ok {
+		s := v.String()
+		res.Version = &s
+	}
+
+	return res, nil
+}
+
+func (h *Handler) sort(r *http.Request) (any, error) {
+	var req SortRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	l, err := h.parseList(req.Versions, "versions")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Descending {
+		l.SortDescending(semver.TieBreakPosition)
+	} else {
+		l.SortStable()
+	}
+
+	res := SortResponse{Versions: make([]string, len(l))}
+
+	for i, v := range l {
+		res.Versions[i] = v.String()
+	}
+
+	return res, nil
+}
+
+func (h *Handler) simplify(r *http.Request) (any, error) {
+	var req SimplifyRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	rng, err := h.parseRange(req.Range, "range")
+	if err != nil {
+		return nil, err
+	}
+
+	return SimplifyResponse{Range: rng.Compile().Range().String()}, nil
+}
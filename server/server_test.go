@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	a := assert.New(t)
+
+	h := &Handler{MaxBodyBytes: 512, MaxVersions: 4, MaxLength: 32}
+
+	pairs := []struct {
+		method, path, body string
+		status             int
+		res                string
+	}{
+		{"POST", "/parse", `{"version":"v1.2.3-rc.1+b.2"}`, 200, `{"version":{"version":"1.2.3-rc.1+b.2","major":1,"minor":2,"patch":3,"prerelease":["rc","1"],"build":["b","2"]}}`},
+		{"POST", "/parse", `{"version":"1.2.3"}`, 200, `{"version":{"version":"1.2.3","major":1,"minor":2,"patch":3,"prerelease":[],"build":[]}}`},
+		{"POST", "/parse", `{"range":"^1.2 || 3.x"}`, 200, `{"range":">=1.2.0 <2.0.0 || >=3.0.0 <4.0.0"}`},
+		{"POST", "/parse", `{"version":"1.2"}`, 422, `{"error":{"code":"invalid_version","message":"minor version should be followed by a period","field":"version"}}`},
+		{"POST", "/parse", `{"range":"^a"}`, 422, `{"error":{"code":"invalid_range","message":"invalid major version","field":"range"}}`},
+		{"POST", "/parse", `{}`, 400, `{"error":{"code":"bad_request","message":"exactly one of version and range is required"}}`},
+		{"POST", "/parse", `{"version":"1.0.0","extra":1}`, 400, `{"error":{"code":"bad_request","message":"json: unknown field \"extra\""}}`},
+		{"POST", "/parse", `{"version":"1.0.0"} {}`, 400, `{"error":{"code":"bad_request","message":"junk data after request body"}}`},
+		{"POST", "/parse", `{"version":"1.0.0-aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}`, 413, `{"error":{"code":"too_large","message":"longer than 32 bytes","field":"version"}}`},
+		{"POST", "/compare", `{"a":"1.2.3","b":"1.10.0"}`, 200, `{"result":-1,"precedence":-1}`},
+		{"POST", "/compare", `{"a":"1.0.0+b","b":"1.0.0+a"}`, 200, `{"result":1,"precedence":0}`},
+		{"POST", "/compare", `{"a":"1.0.0","b":"x"}`, 422, `{"error":{"code":"invalid_version","message":"invalid major version","field":"b"}}`},
+		{"POST", "/satisfies", `{"version":"1.4.0","range":"^1.2"}`, 200, `{"satisfies":true}`},
+		{"POST", "/satisfies", `{"version":"2.0.0","range":"^1.2"}`, 200, `{"satisfies":false}`},
+		{"POST", "/max-satisfying", `{"versions":["1.2.3","1.10.0","2.0.0","1.4.0"],"range":"^1.2"}`, 200, `{"version":"1.10.0"}`},
+		{"POST", "/max-satisfying", `{"versions":["1.2.3"],"range":">=2"}`, 200, `{"version":null}`},
+		{"POST", "/max-satisfying", `{"versions":["1.2.3","nope"],"range":"*"}`, 422, `{"error":{"code":"invalid_version","message":"invalid major version","field":"versions[1]"}}`},
+		{"POST", "/max-satisfying", `{"versions":["1.0.0","1.0.0","1.0.0","1.0.0","1.0.0"],"range":"*"}`, 413, `{"error":{"code":"too_large","message":"more than 4 versions","field":"versions"}}`},
+		{"POST", "/sort", `{"versions":["1.10.0","1.0.0+b","v1.2.3","1.0.0+a"]}`, 200, `{"versions":["1.0.0+b","1.0.0+a","1.2.3","1.10.0"]}`},
+		{"POST", "/sort", `{"versions":["1.10.0","1.0.0+b","1.2.3","1.0.0+a"],"descending":true}`, 200, `{"versions":["1.10.0","1.2.3","1.0.0+b","1.0.0+a"]}`},
+		{"POST", "/simplify", `{"range":">=1.0.0 <1.5.0 || ^1.2.0"}`, 200, `{"range":">=1.0.0 <2.0.0"}`},
+		{"POST", "/simplify", `{"range":"<1.0.0 >2.0.0"}`, 200, `{"range":"<0.0.0-0"}`},
+		{"POST", "/simplify", `{"range":"` + strings.Repeat(" ", 600) + `"}`, 413, `{"error":{"code":"too_large","message":"request body is larger than 512 bytes"}}`},
+		{"POST", "/simplify", `{"range":`, 400, `{"error":{"code":"bad_request","message":"unexpected EOF"}}`},
+		{"GET", "/simplify", ``, 405, `{"error":{"code":"method_not_allowed","message":"only POST is allowed"}}`},
+		{"POST", "/nope", `{}`, 404, `{"error":{"code":"not_found","message":"no such endpoint"}}`},
+	}
+
+	for i, p := range pairs {
+		req := httptest.NewRequest(p.method, p.path, strings.NewReader(p.body))
+		rec := httptest.NewRecorder()
+
+		h.ServeHTTP(rec, req)
+
+		a.Equal(p.status, rec.Code, "[%d] %s %s", i, p.path, p.body)
+		a.Equal("application/json", rec.Header().Get("Content-Type"), "[%d]", i)
+		a.JSONEq(p.res, rec.Body.String(), "[%d] %s %s", i, p.path, p.body)
+	}
+}
+
+func TestHandlerMount(t *testing.T) {
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.Handle("/semver/", http.StripPrefix("/semver", &Handler{}))
+
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	res, err := http.Post(s.URL+"/semver/satisfies", "application/json", strings.NewReader(`{"version":"1.2.3","range":"~1.2"}`))
+	if !a.NoError(err) {
+		return
+	}
+	defer res.Body.Close()
+
+	a.Equal(http.StatusOK, res.StatusCode)
+}
@@ -0,0 +1,250 @@
+// Package tmplfunc provides template functions for working with versions and
+// ranges, for use with both text/template and html/template:
+//
+//	{{ semverCompare "^1.2" .Version }}
+//	{{ (semver .Version).Major }}.{{ semverMinor .Version }}
+//	{{ semverBump "minor" .Version }}
+//	{{ range semverSort .Releases }}{{ . }} {{ end }}
+//
+// Functions that take a version accept a string, a semver.Version or a
+// fmt.Stringer, and functions that take a list accept a slice of any of
+// those. A value that doesn't parse makes the template fail to execute with
+// the parser's error.
+package tmplfunc
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"reflect"
+	"text/template"
+
+	"github.com/deoxxa/semver"
+)
+
+// FuncMap returns the functions for use with text/template.
+//
+//	semver V                 parse V as a version
+//	semverRange R            parse R as a range
+//	semverCompare R V        whether V satisfies range R
+//	semverBump T V           V bumped by release type T: major, minor or patch
+//	semverMajor V            the major, minor or patch number of V
+//	semverMinor V
+//	semverPatch V
+//	semverPrerelease V       the prerelease of V, like "rc.1", or ""
+//	semverSort L             the versions in L, sorted stably by precedence
+//	semverMaxSatisfying R L  the highest version in L satisfying R
+//	semverDiff A B           the release type between A and B, like "minor"
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"semver":              toVersion,
+		"semverRange":         toRange,
+		"semverCompare":       compare,
+		"semverBump":          bump,
+		"semverMajor":         major,
+		"semverMinor":         minor,
+		"semverPatch":         patch,
+		"semverPrerelease":    prerelease,
+		"semverSort":          sortList,
+		"semverMaxSatisfying": maxSatisfying,
+		"semverDiff":          diff,
+	}
+}
+
+// HTMLFuncMap returns the same functions as FuncMap, for html/template.
+func HTMLFuncMap() htmltemplate.FuncMap {
+	return htmltemplate.FuncMap(FuncMap())
+}
+
+func toVersion(v any) (semver.Version, error) {
+	var s string
+
+	switch v := v.(type) {
+	case semver.Version:
+		return v, nil
+	case *semver.Version:
+		if v == nil {
+			return semver.Version{}, fmt.Errorf("nil version")
+		}
+
+		return *v, nil
+	case string:
+		s = v
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return semver.Version{}, fmt.Errorf("%T: can't be used as a version", v)
+	}
+
+	r, err := semver.ParseVersion(s)
+	if err != nil {
+		return r, fmt.Errorf("%s: %w", s, err)
+	}
+
+	return r, nil
+}
+
+func toRange(v any) (semver.Range, error) {
+	switch v := v.(type) {
+	case semver.Range:
+		return v, nil
+	case string:
+		r, err := semver.ParseRange(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v, err)
+		}
+
+		return r, nil
+	}
+
+	return nil, fmt.Errorf("%T: can't be used as a range", v)
+}
+
+func toList(v any) (semver.List, error) {
+	switch v := v.(type) {
+	case semver.List:
+		return append(semver.List(nil), v...), nil
+	case []semver.Version:
+		return append(semver.List(nil), v...), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%T: can't be used as a list of versions", v)
+	}
+
+	l := make(semver.List, rv.Len())
+
+	for i := range l {
+		ver, err := toVersion(rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		l[i] = ver
+	}
+
+	return l, nil
+}
+
+func compare(r, v any) (bool, error) {
+	rng, err := toRange(r)
+	if err != nil {
+		return false, err
+	}
+
+	ver, err := toVersion(v)
+	if err != nil {
+		return false, err
+	}
+
+	return rng.SatisfiedBy(ver), nil
+}
+
+// bump follows the same rules as npm version: a prerelease is released as
+// the version it leads up to when that's at least the requested bump.
+func bump(t string, v any) (semver.Version, error) {
+	ver, err := toVersion(v)
+	if err != nil {
+		return ver, err
+	}
+
+	pre := len(ver.Prerelease) > 0
+
+	switch semver.ReleaseType(t) {
+	case semver.ReleaseMajor:
+		if !pre || ver.Minor != 0 || ver.Patch != 0 {
+			ver.Major++
+		}
+
+		ver.Minor, ver.Patch = 0, 0
+	case semver.ReleaseMinor:
+		if !pre || ver.Patch != 0 {
+			ver.Minor++
+		}
+
+		ver.Patch = 0
+	case semver.ReleasePatch:
+		if !pre {
+			ver.Patch++
+		}
+	default:
+		return semver.Version{}, fmt.Errorf("%s: unknown release type", t)
+	}
+
+	ver.Prerelease, ver.Build = nil, nil
+
+	return ver, nil
+}
+
+func major(v any) (int64, error) {
+	ver, err := toVersion(v)
+
+	return ver.Major, err
+}
+
+func minor(v any) (int64, error) {
+	ver, err := toVersion(v)
+
+	return ver.Minor, err
+}
+
+func patch(v any) (int64, error) {
+	ver, err := toVersion(v)
+
+	return ver.Patch, err
+}
+
+func prerelease(v any) (string, error) {
+	ver, err := toVersion(v)
+	if err != nil || len(ver.Prerelease) == 0 {
+		return "", err
+	}
+
+	ver.Major, ver.Minor, ver.Patch, ver.Build = 0, 0, 0, nil
+
+	return ver.String()[len("0.0.0-"):], nil
+}
+
+func sortList(v any) (semver.List, error) {
+	l, err := toList(v)
+	if err != nil {
+		return nil, err
+	}
+
+	l.SortStable()
+
+	return l, nil
+}
+
+func maxSatisfying(r, v any) (semver.Version, error) {
+	rng, err := toRange(r)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	l, err := toList(v)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	ver, ok := rng.BestMatchSeq(l.Values())
+	if !ok {
+		return ver, fmt.Errorf("%s: no version satisfies range", rng)
+	}
+
+	return ver, nil
+}
+
+func diff(a, b any) (string, error) {
+	va, err := toVersion(a)
+	if err != nil {
+		return "", err
+	}
+
+	vb, err := toVersion(b)
+	if err != nil {
+		return "", err
+	}
+
+	return string(semver.Diff(va, vb)), nil
+}
@@ -0,0 +1,103 @@
+package tmplfunc
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+func TestFuncMap(t *testing.T) {
+	a := assert.New(t)
+
+	v, err := semver.ParseVersion("1.2.3-rc.1")
+	a.NoError(err)
+
+	data := map[string]any{
+		"Version":  "1.4.2",
+		"Parsed":   v,
+		"Releases": []string{"1.10.0", "1.2.0", "v1.9.0", "2.1.0-beta"},
+		"List":     semver.List{v},
+	}
+
+	pairs := []struct {
+		tmpl, out string
+	}{
+		{`{{ semver .Version }}`, "1.4.2"},
+		{`{{ (semver "v1.2.3+b").Major }}`, "1"},
+		{`{{ semverCompare "^1.2" .Version }}`, "true"},
+		{`{{ if semverCompare ">=2" .Version }}new{{ else }}old{{ end }}`, "old"},
+		{`{{ semverCompare (semverRange "~1.4") .Parsed }}`, "false"},
+		{`{{ semverMajor .Version }}.{{ semverMinor .Version }}.{{ semverPatch .Parsed }}`, "1.4.3"},
+		{`{{ semverPrerelease .Parsed }}|{{ semverPrerelease .Version }}`, "rc.1|"},
+		{`{{ semverBump "major" .Version }} {{ semverBump "minor" .Version }} {{ semverBump "patch" .Version }}`, "2.0.0 1.5.0 1.4.3"},
+		{`{{ semverBump "major" "2.0.0-rc.1" }} {{ semverBump "minor" "1.3.0-rc.1" }} {{ semverBump "patch" .Parsed }}`, "2.0.0 1.3.0 1.2.3"},
+		{`{{ semverBump "minor" "1.3.1-rc.1+b" }}`, "1.4.0"},
+		{`{{ range semverSort .Releases }}{{ . }} {{ end }}`, "1.2.0 1.9.0 1.10.0 2.1.0-beta "},
+		{`{{ semverSort .List }}`, "[1.2.3-rc.1]"},
+		{`{{ semverMaxSatisfying "^1" .Releases }}`, "1.10.0"},
+		{`{{ semverDiff .Version "1.5.0" }}`, "minor"},
+	}
+
+	for _, p := range pairs {
+		tpl, err := template.New("").Funcs(FuncMap()).Parse(p.tmpl)
+		if !a.NoError(err, p.tmpl) {
+			continue
+		}
+
+		var b strings.Builder
+		a.NoError(tpl.Execute(&b, data), p.tmpl)
+		a.Equal(p.out, b.String(), p.tmpl)
+	}
+}
+
+func TestFuncMapErrors(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		tmpl, err string
+	}{
+		{`{{ semver "1.2" }}`, "1.2: minor version should be followed by a period"},
+		{`{{ semverCompare "^a" "1.0.0" }}`, "^a: invalid major version"},
+		{`{{ semverCompare "^1" "nope" }}`, "nope: invalid major version"},
+		{`{{ semverBump "huge" "1.0.0" }}`, "huge: unknown release type"},
+		{`{{ semverMajor 12 }}`, "int: can't be used as a version"},
+		{`{{ semverSort "1.0.0" }}`, "string: can't be used as a list of versions"},
+		{`{{ semverSort (list "1.0.0" "x") }}`, "x: invalid major version"},
+		{`{{ semverMaxSatisfying ">=3" (list) }}`, ">=3.0.0: no version satisfies range"},
+	}
+
+	funcs := FuncMap()
+	funcs["list"] = func(s ...string) []string { return s }
+
+	for _, p := range pairs {
+		tpl, err := template.New("").Funcs(funcs).Parse(p.tmpl)
+		if !a.NoError(err, p.tmpl) {
+			continue
+		}
+
+		err = tpl.Execute(&strings.Builder{}, nil)
+		if a.Error(err, p.tmpl) {
+			a.Contains(err.Error(), p.err, p.tmpl)
+		}
+	}
+}
+
+func TestHTMLFuncMap(t *testing.T) {
+	a := assert.New(t)
+
+	tpl, err := htmltemplate.New("").Funcs(HTMLFuncMap()).Parse(`<b>{{ semverBump "minor" . }}</b>{{ if semverCompare "<2" . }} <i>{{ semver . }}</i>{{ end }}`)
+	if !a.NoError(err) {
+		return
+	}
+
+	var b strings.Builder
+	a.NoError(tpl.Execute(&b, "1.2.3"))
+	a.Equal("<b>1.3.0</b> <i>1.2.3</i>", b.String())
+
+	a.Error(tpl.Execute(&strings.Builder{}, "<script>"))
+}
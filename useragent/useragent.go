@@ -0,0 +1,269 @@
+// Package useragent gates features on the versions of the clients named in
+// User-Agent headers.
+//
+// A User-Agent is a list of products, each a name with an optional version,
+// mixed with parenthesised comments, as described in RFC 9110 section 10.1.5:
+//
+//	MyApp/2.3.1 (iPhone; iOS 17.2) CFNetwork/1490.0.4 Darwin/23.2.0
+//
+// Product versions are rarely semver, so they're coerced: "17.2" is read as
+// 17.2.0 and "120.0.6099.109" as 120.0.6099.
+package useragent
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/deoxxa/semver"
+)
+
+// Product is a product token from a User-Agent. Version is empty if the
+// token had none.
+type Product struct {
+	Name    string
+	Version string
+}
+
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) != -1
+}
+
+// Products returns the product tokens in ua, in order, skipping comments.
+// It's lenient: characters that can't start a token are skipped rather than
+// treated as an error, since real clients send all sorts.
+func Products(ua string) []Product {
+	var l []Product
+
+	for i := 0; i < len(ua); {
+		switch c := ua[i]; {
+		case c == '(':
+			i = skipComment(ua, i)
+		case isTokenChar(c):
+			start := i
+			for i < len(ua) && isTokenChar(ua[i]) {
+				i++
+			}
+
+			p := Product{Name: ua[start:i]}
+
+			if i < len(ua) && ua[i] == '/' {
+				i++
+
+				start = i
+				for i < len(ua) && isTokenChar(ua[i]) {
+					i++
+				}
+
+				p.Version = ua[start:i]
+			}
+
+			l = append(l, p)
+		default:
+			i++
+		}
+	}
+
+	return l
+}
+
+// skipComment returns the index just past the comment starting at ua[i],
+// which may contain nested comments and backslash escapes. An unterminated
+// comment runs to the end of ua.
+func skipComment(ua string, i int) int {
+	depth := 0
+
+	for ; i < len(ua); i++ {
+		switch ua[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return len(ua)
+}
+
+// Coerce reads s as a version if it's valid semver, optionally with a
+// leading "v", and otherwise takes up to three dot-separated numbers from
+// its start, filling in any that are missing with zero. It fails if s
+// doesn't start with a number.
+func Coerce(s string) (semver.Version, bool) {
+	if v, err := semver.ParseVersion(s); err == nil {
+		return v, true
+	}
+
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+
+	var parts [3]int64
+
+	n := 0
+
+	for n < len(parts) {
+		end := 0
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+
+		if end == 0 {
+			break
+		}
+
+		x, err := strconv.ParseInt(s[:end], 10, 64)
+		if err != nil {
+			return semver.Version{}, false
+		}
+
+		parts[n] = x
+		n++
+
+		if end == len(s) || s[end] != '.' {
+			break
+		}
+
+		s = s[end+1:]
+	}
+
+	if n == 0 {
+		return semver.Version{}, false
+	}
+
+	return semver.Version{Major: parts[0], Minor: parts[1], Patch: parts[2]}, true
+}
+
+// Client is a product from a User-Agent whose version could be coerced.
+type Client struct {
+	Name    string
+	Version semver.Version
+}
+
+// Clients returns the products in ua that have a version Coerce accepts.
+func Clients(ua string) []Client {
+	var l []Client
+
+	for _, p := range Products(ua) {
+		if v, ok := Coerce(p.Version); ok {
+			l = append(l, Client{Name: p.Name, Version: v})
+		}
+	}
+
+	return l
+}
+
+// Rule turns Feature on for clients that send Product, compared without
+// regard to case, with a version in Range.
+type Rule struct {
+	Feature string
+	Product string
+	Range   semver.Range
+}
+
+// Gate decides what each client may do. Clients that send a product named
+// in Supported with a version outside its range are rejected; clients that
+// don't send the product at all aren't. Features are on for a client if any
+// of the Rules for them match.
+type Gate struct {
+	Rules     []Rule
+	Supported map[string]semver.Range
+
+	// Reject handles rejected requests. If it's nil they get a 403.
+	Reject http.Handler
+}
+
+// Info is what a Gate found out about a request's client.
+type Info struct {
+	Clients  []Client
+	Features map[string]bool
+
+	// Unsupported is the client that caused the request to be rejected,
+	// if it was.
+	Unsupported *Client
+}
+
+func (i *Info) Enabled(feature string) bool {
+	return i != nil && i.Features[feature]
+}
+
+func find(clients []Client, product string) (Client, bool) {
+	for _, c := range clients {
+		if strings.EqualFold(c.Name, product) {
+			return c, true
+		}
+	}
+
+	return Client{}, false
+}
+
+// Check evaluates the gate against a User-Agent.
+func (g *Gate) Check(ua string) *Info {
+	info := Info{Clients: Clients(ua), Features: map[string]bool{}}
+
+	for _, product := range slices.Sorted(maps.Keys(g.Supported)) {
+		if c, ok := find(info.Clients, product); ok && !g.Supported[product].SatisfiedBy(c.Version) {
+			info.Unsupported = &c
+			break
+		}
+	}
+
+	for _, rule := range g.Rules {
+		if c, ok := find(info.Clients, rule.Product); ok && rule.Range.SatisfiedBy(c.Version) {
+			info.Features[rule.Feature] = true
+		}
+	}
+
+	return &info
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+func FromContext(ctx context.Context) (*Info, bool) {
+	info, ok := ctx.Value(contextKey{}).(*Info)
+	return info, ok
+}
+
+// Enabled reports whether the Gate that handled a request turned feature on
+// for it.
+func Enabled(ctx context.Context, feature string) bool {
+	info, _ := FromContext(ctx)
+	return info.Enabled(feature)
+}
+
+// Handler checks the User-Agent of each request, and passes those it
+// doesn't reject on to next with the result in their context. Rejected
+// requests also carry it, for Reject to use.
+func (g *Gate) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := g.Check(r.UserAgent())
+		r = r.WithContext(NewContext(r.Context(), info))
+
+		if info.Unsupported != nil {
+			if g.Reject != nil {
+				g.Reject.ServeHTTP(w, r)
+			} else {
+				http.Error(w, "client version "+info.Unsupported.Version.String()+" is not supported", http.StatusForbidden)
+			}
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,176 @@
+package useragent
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/deoxxa/semver"
+)
+
+const (
+	chrome  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36"
+	firefox = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+	safari  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1"
+	curl    = "curl/8.4.0"
+	okhttp  = "okhttp/4.9.3"
+	app     = "MyApp/2.3.0-beta.1 (iPhone; iOS 17.2; Scale/3.00) CFNetwork/1490.0.4 Darwin/23.2.0"
+	oldApp  = "MyApp/1.9.12 (Linux; U; Android 13; (nested) comment \\) here) okhttp/4.9.3"
+	goHTTP  = "Go-http-client/1.1"
+)
+
+func TestProducts(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		ua  string
+		out []Product
+	}{
+		{curl, []Product{{"curl", "8.4.0"}}},
+		{goHTTP, []Product{{"Go-http-client", "1.1"}}},
+		{chrome, []Product{{"Mozilla", "5.0"}, {"AppleWebKit", "537.36"}, {"Chrome", "120.0.6099.109"}, {"Safari", "537.36"}}},
+		{firefox, []Product{{"Mozilla", "5.0"}, {"Gecko", "20100101"}, {"Firefox", "121.0"}}},
+		{safari, []Product{{"Mozilla", "5.0"}, {"AppleWebKit", "605.1.15"}, {"Version", "17.2"}, {"Mobile", "15E148"}, {"Safari", "604.1"}}},
+		{app, []Product{{"MyApp", "2.3.0-beta.1"}, {"CFNetwork", "1490.0.4"}, {"Darwin", "23.2.0"}}},
+		{oldApp, []Product{{"MyApp", "1.9.12"}, {"okhttp", "4.9.3"}}},
+		{"Bot (unterminated", []Product{{"Bot", ""}}},
+		{"", nil},
+	}
+
+	for _, p := range pairs {
+		a.Equal(p.out, Products(p.ua), p.ua)
+	}
+}
+
+func TestCoerce(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []struct {
+		in, out string
+		ok      bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"v1.2.3-rc.1", "1.2.3-rc.1", true},
+		{"17.2", "17.2.0", true},
+		{"5", "5.0.0", true},
+		{"120.0.6099.109", "120.0.6099", true},
+		{"15E148", "15.0.0", true},
+		{"1.0b3", "1.0.0", true},
+		{"V2.1", "2.1.0", true},
+		{"", "", false},
+		{"beta", "", false},
+		{"99999999999999999999", "", false},
+	}
+
+	for _, p := range pairs {
+		v, ok := Coerce(p.in)
+		a.Equal(p.ok, ok, p.in)
+
+		if ok {
+			a.Equal(p.out, v.String(), p.in)
+		}
+	}
+}
+
+func mustRange(s string) semver.Range {
+	r, err := semver.ParseRange(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+func TestGate(t *testing.T) {
+	a := assert.New(t)
+
+	g := &Gate{
+		Rules: []Rule{
+			{Feature: "dark-mode", Product: "myapp", Range: mustRange(">=2.0.0")},
+			{Feature: "dark-mode", Product: "Firefox", Range: mustRange(">=120.0.0")},
+			{Feature: "http3", Product: "Chrome", Range: mustRange(">=110.0.0")},
+			{Feature: "legacy-sync", Product: "MyApp", Range: mustRange("<2.0.0")},
+		},
+		Supported: map[string]semver.Range{
+			"MyApp":  mustRange(">=1.5.0"),
+			"Chrome": mustRange(">=100.0.0"),
+		},
+	}
+
+	pairs := []struct {
+		ua       string
+		status   int
+		features []string
+	}{
+		{app, 200, []string{"dark-mode"}},
+		{oldApp, 200, []string{"legacy-sync"}},
+		{"MyApp/1.4.0 okhttp/4.9.3", 403, nil},
+		{chrome, 200, []string{"http3"}},
+		{"Mozilla/5.0 (Windows NT 10.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.51 Safari/537.36", 403, nil},
+		{firefox, 200, []string{"dark-mode"}},
+		{safari, 200, nil},
+		{curl, 200, nil},
+		{"", 200, nil},
+	}
+
+	for _, p := range pairs {
+		var info *Info
+
+		h := g.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info, _ = FromContext(r.Context())
+		}))
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", p.ua)
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, r)
+
+		a.Equal(p.status, w.Code, p.ua)
+
+		if p.status != 200 {
+			a.Nil(info, p.ua)
+			continue
+		}
+
+		if !a.NotNil(info, p.ua) {
+			continue
+		}
+
+		var features []string
+		for _, f := range []string{"dark-mode", "http3", "legacy-sync"} {
+			if info.Enabled(f) {
+				features = append(features, f)
+			}
+		}
+
+		a.Equal(p.features, features, p.ua)
+	}
+
+	a.False(Enabled(httptest.NewRequest("GET", "/", nil).Context(), "dark-mode"))
+}
+
+func TestGateReject(t *testing.T) {
+	a := assert.New(t)
+
+	g := &Gate{
+		Supported: map[string]semver.Range{"MyApp": mustRange(">=2.0.0")},
+		Reject: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info, _ := FromContext(r.Context())
+			w.WriteHeader(http.StatusUpgradeRequired)
+			io.WriteString(w, info.Unsupported.Name+" "+info.Unsupported.Version.String())
+		}),
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", oldApp)
+	w := httptest.NewRecorder()
+
+	g.Handler(http.NotFoundHandler()).ServeHTTP(w, r)
+
+	a.Equal(http.StatusUpgradeRequired, w.Code)
+	a.Equal("MyApp 1.9.12", w.Body.String())
+}
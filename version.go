@@ -2,10 +2,7 @@ package semver
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
-
-	"go.bmatsuo.co/go-lexer"
 )
 
 type Version struct {
@@ -27,26 +24,44 @@ func (v Version) String() string {
 	return s
 }
 
+func isNumeric(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
 func compareTags(a, b string) int {
 	if a == b {
 		return 0
 	}
 
-	d1, err1 := strconv.Atoi(a)
-	d2, err2 := strconv.Atoi(b)
+	switch an, bn := isNumeric(a), isNumeric(b); {
+	case an && bn:
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
 
-	if err1 == nil && err2 == nil {
-		if d1 > d2 {
+		if len(a) > len(b) {
 			return 1
-		} else if d1 < d2 {
-			return -1
-		}
-	} else {
-		if a > b {
-			return 1
-		} else if a < b {
+		} else if len(a) < len(b) {
 			return -1
 		}
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+
+	if a > b {
+		return 1
+	} else if a < b {
+		return -1
 	}
 
 	return 0
@@ -72,229 +87,74 @@ func (v Version) EqualTo(other Version) bool {
 	return true
 }
 
-func (v Version) GreaterThan(other Version) bool {
+// comparePrecedence orders versions by semver precedence, which ignores
+// build metadata.
+func comparePrecedence(v, other Version) int {
 	if v.Major > other.Major {
-		return true
+		return 1
 	} else if v.Major < other.Major {
-		return false
+		return -1
 	}
 
 	if v.Minor > other.Minor {
-		return true
+		return 1
 	} else if v.Minor < other.Minor {
-		return false
+		return -1
 	}
 
 	if v.Patch > other.Patch {
-		return true
+		return 1
 	} else if v.Patch < other.Patch {
-		return false
+		return -1
 	}
 
 	if len(v.Prerelease) == 0 && len(other.Prerelease) > 0 {
-		return true
+		return 1
 	} else if len(v.Prerelease) > 0 && len(other.Prerelease) == 0 {
-		return false
+		return -1
 	}
 
 	for i, j := 0, min(len(v.Prerelease), len(other.Prerelease)); i < j; i++ {
-		if c := compareTags(v.Prerelease[i], other.Prerelease[i]); c > 0 {
-			return true
-		} else if c < 0 {
-			return false
+		if c := compareTags(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
 		}
 	}
 
 	if len(v.Prerelease) > len(other.Prerelease) {
-		return true
+		return 1
+	} else if len(v.Prerelease) < len(other.Prerelease) {
+		return -1
 	}
 
-	for i, j := 0, min(len(v.Build), len(other.Build)); i < j; i++ {
-		if c := compareTags(v.Build[i], other.Build[i]); c > 0 {
-			return true
-		} else if c < 0 {
-			return false
-		}
-	}
-
-	return false
+	return 0
 }
 
-func (v Version) LessThan(other Version) bool {
-	if v.Major < other.Major {
-		return true
-	} else if v.Major > other.Major {
-		return false
-	}
-
-	if v.Minor < other.Minor {
-		return true
-	} else if v.Minor > other.Minor {
-		return false
-	}
-
-	if v.Patch < other.Patch {
-		return true
-	} else if v.Patch > other.Patch {
-		return false
-	}
-
-	if len(v.Prerelease) > 0 && len(other.Prerelease) == 0 {
-		return true
-	} else if len(v.Prerelease) == 0 && len(other.Prerelease) > 0 {
-		return false
-	}
-
-	for i, j := 0, min(len(v.Prerelease), len(other.Prerelease)); i < j; i++ {
-		if c := compareTags(v.Prerelease[i], other.Prerelease[i]); c < 0 {
-			return true
-		} else if c > 0 {
-			return false
-		}
-	}
-
-	if len(v.Prerelease) < len(other.Prerelease) {
-		return true
+// Compare orders versions by precedence, then by build metadata so that
+// versions differing only in their builds still sort deterministically.
+func (v Version) Compare(other Version) int {
+	if c := comparePrecedence(v, other); c != 0 {
+		return c
 	}
 
 	for i, j := 0, min(len(v.Build), len(other.Build)); i < j; i++ {
-		if c := compareTags(v.Build[i], other.Build[i]); c < 0 {
-			return true
-		} else if c > 0 {
-			return false
-		}
-	}
-
-	return false
-}
-
-func stateVersion(l *lexer.Lexer) lexer.StateFn {
-	if l.AcceptRun(whitespace) > 0 {
-		l.Ignore()
-	}
-
-	if l.Accept("vV") {
-		l.Ignore()
-	}
-
-	if l.AcceptRun(whitespace) > 0 {
-		l.Ignore()
-	}
-
-	if l.AcceptRun("0123456789") == 0 {
-		return l.Errorf("invalid major version")
-	} else {
-		l.Emit(ItemMajor)
-	}
-
-	if !l.Accept(".") {
-		return l.Errorf("major version should be followed by a period")
-	} else {
-		l.Ignore()
-	}
-
-	if l.AcceptRun("0123456789") == 0 {
-		return l.Errorf("invalid minor version")
-	} else {
-		l.Emit(ItemMinor)
-	}
-
-	if !l.Accept(".") {
-		return l.Errorf("minor version should be followed by a period")
-	} else {
-		l.Ignore()
-	}
-
-	if l.AcceptRun("0123456789") == 0 {
-		return l.Errorf("invalid patch version")
-	} else {
-		l.Emit(ItemPatch)
-	}
-
-	if l.Accept("-") {
-		l.Ignore()
-
-		for {
-			if l.AcceptRun(tagchars) == 0 {
-				return l.Errorf("invalid prerelease component")
-			} else {
-				l.Emit(ItemPrerelease)
-			}
-
-			if !l.Accept(".") {
-				break
-			} else {
-				l.Ignore()
-			}
+		if c := compareTags(v.Build[i], other.Build[i]); c != 0 {
+			return c
 		}
 	}
 
-	if l.Accept("+") {
-		l.Ignore()
-
-		for {
-			if l.AcceptRun(tagchars) == 0 {
-				return l.Errorf("invalid prerelease component")
-			} else {
-				l.Emit(ItemBuild)
-			}
-
-			if !l.Accept(".") {
-				break
-			} else {
-				l.Ignore()
-			}
-		}
-	}
-
-	if lexer.IsEOF(l.Peek()) {
-		return nil
+	if len(v.Build) > len(other.Build) {
+		return 1
+	} else if len(v.Build) < len(other.Build) {
+		return -1
 	}
 
-	return l.Errorf("junk data after version")
+	return 0
 }
 
-func ParseVersion(ver string) (Version, error) {
-	var v Version
-
-	l := lexer.New(stateVersion, ver)
-
-	for {
-		t := l.Next()
-
-		if t.Type == lexer.ItemEOF {
-			break
-		}
-
-		if t.Type == lexer.ItemError {
-			return v, t.Err()
-		}
-
-		switch t.Type {
-		case ItemMajor:
-			if d, err := strconv.ParseInt(t.Value, 10, 32); err != nil {
-				return v, err
-			} else {
-				v.Major = d
-			}
-		case ItemMinor:
-			if d, err := strconv.ParseInt(t.Value, 10, 32); err != nil {
-				return v, err
-			} else {
-				v.Minor = d
-			}
-		case ItemPatch:
-			if d, err := strconv.ParseInt(t.Value, 10, 32); err != nil {
-				return v, err
-			} else {
-				v.Patch = d
-			}
-		case ItemPrerelease:
-			v.Prerelease = append(v.Prerelease, t.Value)
-		case ItemBuild:
-			v.Build = append(v.Build, t.Value)
-		}
-	}
+func (v Version) GreaterThan(other Version) bool {
+	return v.Compare(other) > 0
+}
 
-	return v, nil
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
 }